@@ -0,0 +1,112 @@
+package modrank
+
+import (
+	"regexp"
+	"sort"
+)
+
+// ModuleAlias is the mapping from the old module path to the new one, such as the moved or forked library.
+type ModuleAlias struct {
+	// From is the old module path. e.g.) github.com/golang/protobuf
+	From string
+	// To is the new module path. e.g.) google.golang.org/protobuf
+	To string
+	// Link if true, the score of the From module isn't merged into the To module,
+	// and the From module refers the To module by GoModuleScore.LinkedTo.
+	Link bool
+}
+
+// builtinModuleAliases is the list of well-known moved or forked libraries.
+var builtinModuleAliases = []*ModuleAlias{
+	{From: "github.com/golang/protobuf", To: "google.golang.org/protobuf"},
+	{From: "github.com/Sirupsen/logrus", To: "github.com/sirupsen/logrus"},
+	{From: "github.com/satori/go.uuid", To: "github.com/gofrs/uuid"},
+	{From: "github.com/dgrijalva/jwt-go", To: "github.com/golang-jwt/jwt"},
+	{From: "github.com/form3tech-oss/jwt-go", To: "github.com/golang-jwt/jwt"},
+	{From: "github.com/ghodss/yaml", To: "sigs.k8s.io/yaml"},
+	{From: "github.com/mitchellh/mapstructure", To: "github.com/go-viper/mapstructure"},
+}
+
+// maxModuleAliasDepth is the limit to follow the chain of aliases to avoid infinite loop by cyclic aliases.
+const maxModuleAliasDepth = 16
+
+var moduleMajorVersionPat = regexp.MustCompile(`/v[0-9]+$`)
+
+// moduleAliases resolves the canonical module path by the alias table.
+type moduleAliases struct {
+	aliases            map[string]*ModuleAlias
+	mergeMajorVersions bool
+}
+
+func newModuleAliases() *moduleAliases {
+	return &moduleAliases{aliases: make(map[string]*ModuleAlias)}
+}
+
+func (a *moduleAliases) add(alias *ModuleAlias) {
+	a.aliases[alias.From] = alias
+}
+
+// canonical returns the module path that the name is merged into, and whether the name is linked instead of merged.
+func (a *moduleAliases) canonical(name string) (string, bool) {
+	var linked bool
+	for range maxModuleAliasDepth {
+		next := name
+		if a.mergeMajorVersions {
+			next = moduleMajorVersionPat.ReplaceAllString(next, "")
+		}
+		if alias, exists := a.aliases[next]; exists {
+			next = alias.To
+			linked = linked || alias.Link
+		}
+		if next == name {
+			break
+		}
+		name = next
+	}
+	return name, linked
+}
+
+// merge merges the scores of the module names into the canonical module.
+// The original scores are kept in GoModuleScore.Aliases of the merged score.
+func (a *moduleAliases) merge(scores []*GoModuleScore) []*GoModuleScore {
+	if len(a.aliases) == 0 && !a.mergeMajorVersions {
+		return scores
+	}
+	var (
+		results []*GoModuleScore
+		merged  = make(map[string]*GoModuleScore)
+	)
+	for _, score := range scores {
+		name, linked := a.canonical(score.Name)
+		if name == score.Name {
+			linked = false
+		}
+		if linked {
+			score.LinkedTo = name
+			results = append(results, score)
+			continue
+		}
+		m, exists := merged[name]
+		if !exists {
+			m = &GoModuleScore{Name: name}
+			merged[name] = m
+			results = append(results, m)
+		}
+		m.Score += score.Score
+		m.Aliases = append(m.Aliases, score)
+		if score.Name == name || m.Repository == "" {
+			m.Repository = score.Repository
+			m.Subdirectory = score.Subdirectory
+		}
+	}
+	for _, m := range merged {
+		if len(m.Aliases) == 1 && m.Aliases[0].Name == m.Name {
+			m.Aliases = nil
+			continue
+		}
+		sort.Slice(m.Aliases, func(i, j int) bool {
+			return m.Aliases[i].Name < m.Aliases[j].Name
+		})
+	}
+	return results
+}
@@ -0,0 +1,57 @@
+package modrank
+
+import (
+	"testing"
+)
+
+func TestModuleAliases(t *testing.T) {
+	aliases := newModuleAliases()
+	aliases.mergeMajorVersions = true
+	for _, alias := range builtinModuleAliases {
+		aliases.add(alias)
+	}
+	aliases.add(&ModuleAlias{From: "github.com/example/old", To: "github.com/example/new", Link: true})
+
+	scores := aliases.merge([]*GoModuleScore{
+		{Name: "google.golang.org/protobuf", Repository: "github.com/protocolbuffers/protobuf-go", Score: 10},
+		{Name: "github.com/golang/protobuf", Repository: "github.com/golang/protobuf", Score: 5},
+		{Name: "github.com/dgrijalva/jwt-go", Repository: "github.com/dgrijalva/jwt-go", Score: 1},
+		{Name: "github.com/golang-jwt/jwt/v4", Repository: "github.com/golang-jwt/jwt", Score: 2},
+		{Name: "github.com/golang-jwt/jwt/v5", Repository: "github.com/golang-jwt/jwt", Score: 3},
+		{Name: "github.com/example/old", Repository: "github.com/example/old", Score: 4},
+		{Name: "github.com/goccy/go-yaml", Repository: "github.com/goccy/go-yaml", Score: 7},
+	})
+	byName := make(map[string]*GoModuleScore)
+	for _, score := range scores {
+		byName[score.Name] = score
+	}
+	if len(scores) != 4 {
+		t.Fatalf("unexpected number of scores: %d", len(scores))
+	}
+
+	protobuf := byName["google.golang.org/protobuf"]
+	if protobuf.Score != 15 || protobuf.Repository != "github.com/protocolbuffers/protobuf-go" {
+		t.Fatalf("failed to merge moved module: %+v", protobuf)
+	}
+	if len(protobuf.Aliases) != 2 || protobuf.Aliases[0].Name != "github.com/golang/protobuf" || protobuf.Aliases[0].Score != 5 {
+		t.Fatalf("failed to keep original names: %+v", protobuf.Aliases)
+	}
+
+	// the alias of the old module and the major versions are merged into the same module.
+	jwt := byName["github.com/golang-jwt/jwt"]
+	if jwt.Score != 6 || len(jwt.Aliases) != 3 {
+		t.Fatalf("failed to merge major versions: %+v", jwt)
+	}
+
+	old := byName["github.com/example/old"]
+	if old.Score != 4 || old.LinkedTo != "github.com/example/new" {
+		t.Fatalf("failed to link module: %+v", old)
+	}
+	if _, exists := byName["github.com/example/new"]; exists {
+		t.Fatal("linked module must not be merged")
+	}
+
+	if yaml := byName["github.com/goccy/go-yaml"]; yaml.Score != 7 || len(yaml.Aliases) != 0 {
+		t.Fatalf("unexpected score of module without alias: %+v", yaml)
+	}
+}
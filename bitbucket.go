@@ -0,0 +1,279 @@
+package modrank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/goccy/go-modrank/repository"
+)
+
+const (
+	// DefaultBitbucketAPIURL is the base URL of Bitbucket Cloud REST API.
+	DefaultBitbucketAPIURL = "https://api.bitbucket.org/2.0"
+	// bitbucketHost is the host name of repositories on Bitbucket Cloud.
+	bitbucketHost = "bitbucket.org"
+	// bitbucketMaxDepth is the directory depth to search go.mod.
+	bitbucketMaxDepth = 16
+	// bitbucketAuthUsername is the username to clone repositories by the access token.
+	bitbucketAuthUsername = "x-token-auth"
+)
+
+type BitbucketAccessToken = GitAccessToken
+
+func BitbucketStaticAccessToken(tk string) *BitbucketAccessToken {
+	return &GitAccessToken{
+		issuer: func(_ context.Context) (string, error) {
+			return tk, nil
+		},
+	}
+}
+
+var _ RepositoryHost = new(BitbucketClient)
+
+// BitbucketClient is the client of Bitbucket Cloud REST API. It implements RepositoryHost for repositories on bitbucket.org.
+// The access token must be a workspace, project or repository access token.
+type BitbucketClient struct {
+	httpClientHolder
+
+	apiURL               string
+	bitbucketAccessToken *BitbucketAccessToken
+	repoCache            map[string]*BitbucketRepository
+	repoCacheMu          sync.RWMutex
+}
+
+// BitbucketRepository represents the Bitbucket repository.
+type BitbucketRepository struct {
+	FullName   string `json:"full_name"`
+	MainBranch *struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+	Links struct {
+		Clone []struct {
+			Name string `json:"name"`
+			Href string `json:"href"`
+		} `json:"clone"`
+	} `json:"links"`
+	// HeadCommit is the head commit hash of the main branch. It's set by CreateRepositoryCache.
+	HeadCommit string `json:"-"`
+	// Err is the error of the repository returned by the API such as not found or forbidden. It's set by CreateRepositoryCache.
+	Err error `json:"-"`
+}
+
+// DefaultBranch returns the main branch name. If the repository is empty, returns empty string.
+func (r *BitbucketRepository) DefaultBranch() string {
+	if r.MainBranch == nil {
+		return ""
+	}
+	return r.MainBranch.Name
+}
+
+// CloneURL returns the HTTPS url to clone the repository.
+func (r *BitbucketRepository) CloneURL() string {
+	for _, link := range r.Links.Clone {
+		if link.Name == "https" {
+			// remove the username of the workspace member because the access token is used.
+			parsedURL, err := url.Parse(link.Href)
+			if err != nil {
+				return link.Href
+			}
+			parsedURL.User = nil
+			return parsedURL.String()
+		}
+	}
+	return fmt.Sprintf("https://%s/%s.git", bitbucketHost, r.FullName)
+}
+
+// NewBitbucketClient creates the client for Bitbucket Cloud.
+// If apiURL is empty, DefaultBitbucketAPIURL is used.
+func NewBitbucketClient(ctx context.Context, apiURL string, token *BitbucketAccessToken) *BitbucketClient {
+	if apiURL == "" {
+		apiURL = DefaultBitbucketAPIURL
+	}
+	return &BitbucketClient{
+		apiURL:               apiURL,
+		bitbucketAccessToken: token,
+		repoCache:            make(map[string]*BitbucketRepository),
+	}
+}
+
+// Match reports whether the repository is hosted by Bitbucket Cloud.
+func (c *BitbucketClient) Match(repo *repository.Repository) bool {
+	return repo.Host() == bitbucketHost
+}
+
+// CloneAuth returns the Bitbucket access token of the client to clone repositories.
+func (c *BitbucketClient) CloneAuth() (repository.TokenIssuer, string) {
+	if c.bitbucketAccessToken == nil {
+		return nil, bitbucketAuthUsername
+	}
+	return repository.TokenIssuer(c.bitbucketAccessToken.issuer), bitbucketAuthUsername
+}
+
+// FindRepositoriesByWorkspace returns all repositories in the workspace.
+func (c *BitbucketClient) FindRepositoriesByWorkspace(ctx context.Context, workspace string) ([]*BitbucketRepository, error) {
+	var repos []*BitbucketRepository
+	query := url.Values{}
+	query.Set("pagelen", "100")
+	next := c.apiURL + "/repositories/" + url.PathEscape(workspace) + "?" + query.Encode()
+	for next != "" {
+		var page struct {
+			Values []*BitbucketRepository `json:"values"`
+			Next   string                 `json:"next"`
+		}
+		if err := c.get(ctx, next, &page); err != nil {
+			return nil, fmt.Errorf("failed to get repositories of %s workspace: %w", workspace, err)
+		}
+		repos = append(repos, page.Values...)
+		next = page.Next
+	}
+	return repos, nil
+}
+
+// IsArchived always returns false because Bitbucket Cloud doesn't support archiving repositories.
+func (c *BitbucketClient) IsArchived(ctx context.Context, repo *repository.Repository) (bool, error) {
+	bitbucketRepo := c.getRepositoryFromCache(repo.NameWithOwner())
+	if bitbucketRepo == nil {
+		return false, errors.New("cannot use IsArchived unless you create a cache in advance by CreateRepositoryCache")
+	}
+	return false, bitbucketRepo.Err
+}
+
+func (c *BitbucketClient) GetHeadCommit(ctx context.Context, repo *repository.Repository) (string, error) {
+	bitbucketRepo := c.getRepositoryFromCache(repo.NameWithOwner())
+	if bitbucketRepo == nil {
+		return "", errors.New("cannot use GetHeadCommit unless you create a cache in advance by CreateRepositoryCache")
+	}
+	if bitbucketRepo.Err != nil {
+		return "", bitbucketRepo.Err
+	}
+	return bitbucketRepo.HeadCommit, nil
+}
+
+// ExistsGoMod returns whether go.mod exists in the tree of the head commit of the main branch.
+func (c *BitbucketClient) ExistsGoMod(ctx context.Context, repo *repository.Repository) (bool, error) {
+	head, err := c.GetHeadCommit(ctx, repo)
+	if err != nil {
+		return false, err
+	}
+	if head == "" {
+		return false, nil
+	}
+	query := url.Values{}
+	query.Set("max_depth", fmt.Sprint(bitbucketMaxDepth))
+	query.Set("pagelen", "100")
+	next := c.repositoryURL(repo.Owner(), repo.Name()) + "/src/" + head + "/?" + query.Encode()
+	for next != "" {
+		var page struct {
+			Values []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := c.get(ctx, next, &page); err != nil {
+			if errors.Is(err, errAPINotFound) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to get tree from head commit of main branch: %w", err)
+		}
+		for _, entry := range page.Values {
+			if entry.Type == "commit_file" && path.Base(entry.Path) == "go.mod" {
+				return true, nil
+			}
+		}
+		next = page.Next
+	}
+	return false, nil
+}
+
+// CreateRepositoryCache prefetches the head commit of the main branch of the repositories.
+func (c *BitbucketClient) CreateRepositoryCache(ctx context.Context, repos []*repository.Repository) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(hostAPIConcurrency)
+	for _, repo := range repos {
+		if !c.Match(repo) {
+			continue
+		}
+		eg.Go(func() error {
+			bitbucketRepo, err := c.getRepository(ctx, repo.Owner(), repo.Name())
+			if err != nil {
+				repoErr := newRepositoryError(repo.NameWithOwner(), err)
+				if repoErr == nil {
+					return err
+				}
+				// the repository is deleted or the token has no permission to access it.
+				bitbucketRepo = &BitbucketRepository{FullName: repo.NameWithOwner(), Err: repoErr}
+			}
+			c.setRepositoryCache(repo.NameWithOwner(), bitbucketRepo)
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+func (c *BitbucketClient) getRepository(ctx context.Context, workspace, slug string) (*BitbucketRepository, error) {
+	repoURL := c.repositoryURL(workspace, slug)
+	var bitbucketRepo BitbucketRepository
+	if err := c.get(ctx, repoURL, &bitbucketRepo); err != nil {
+		return nil, fmt.Errorf("failed to get %s/%s repository: %w", workspace, slug, err)
+	}
+	branchName := bitbucketRepo.DefaultBranch()
+	if branchName == "" {
+		return &bitbucketRepo, nil
+	}
+	var branch struct {
+		Target struct {
+			Hash string `json:"hash"`
+		} `json:"target"`
+	}
+	if err := c.get(ctx, repoURL+"/refs/branches/"+url.PathEscape(branchName), &branch); err != nil {
+		if errors.Is(err, errAPINotFound) {
+			// main branch is not pushed yet.
+			return &bitbucketRepo, nil
+		}
+		return nil, fmt.Errorf("failed to get main branch of %s/%s repository: %w", workspace, slug, err)
+	}
+	bitbucketRepo.HeadCommit = branch.Target.Hash
+	return &bitbucketRepo, nil
+}
+
+func (c *BitbucketClient) repositoryURL(workspace, slug string) string {
+	return c.apiURL + "/repositories/" + url.PathEscape(workspace) + "/" + url.PathEscape(slug)
+}
+
+// get calls GET method of Bitbucket REST API and decodes the response to v.
+func (c *BitbucketClient) get(ctx context.Context, reqURL string, v any) error {
+	var authorization string
+	if c.bitbucketAccessToken != nil {
+		tk, err := c.bitbucketAccessToken.issuer(ctx)
+		if err != nil {
+			return fmt.Errorf("modrank: failed to issue Bitbucket API access token: %w", err)
+		}
+		if tk != "" {
+			authorization = "Bearer " + tk
+		}
+	}
+	if _, err := getJSON(ctx, c.client(), reqURL, authorization, v); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *BitbucketClient) getRepositoryFromCache(nameWithOwner string) *BitbucketRepository {
+	c.repoCacheMu.RLock()
+	repo := c.repoCache[nameWithOwner]
+	c.repoCacheMu.RUnlock()
+	return repo
+}
+
+func (c *BitbucketClient) setRepositoryCache(nameWithOwner string, repo *BitbucketRepository) {
+	c.repoCacheMu.Lock()
+	c.repoCache[nameWithOwner] = repo
+	c.repoCacheMu.Unlock()
+}
@@ -0,0 +1,105 @@
+package modrank_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-modrank"
+	"github.com/goccy/go-modrank/repository"
+)
+
+func TestBitbucketClient(t *testing.T) {
+	ctx := context.Background()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var v any
+		switch req.URL.EscapedPath() {
+		case "/repositories/ws":
+			v = map[string]any{
+				"values": []map[string]any{{
+					"full_name":  "ws/app",
+					"mainbranch": map[string]any{"name": "main"},
+					"links": map[string]any{"clone": []map[string]any{
+						{"name": "https", "href": "https://user@bitbucket.org/ws/app.git"},
+						{"name": "ssh", "href": "git@bitbucket.org:ws/app.git"},
+					}},
+				}},
+				"next": server.URL + "/repositories/ws/next",
+			}
+		case "/repositories/ws/next":
+			v = map[string]any{
+				"values": []map[string]any{{"full_name": "ws/empty"}},
+			}
+		case "/repositories/ws/app":
+			v = map[string]any{"full_name": "ws/app", "mainbranch": map[string]any{"name": "main"}}
+		case "/repositories/ws/empty":
+			v = map[string]any{"full_name": "ws/empty"}
+		case "/repositories/ws/app/refs/branches/main":
+			v = map[string]any{"target": map[string]any{"hash": "head"}}
+		case "/repositories/ws/app/src/head/":
+			v = map[string]any{"values": []map[string]any{{"path": "go.mod", "type": "commit_file"}}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer server.Close()
+
+	client := modrank.NewBitbucketClient(ctx, server.URL, modrank.BitbucketStaticAccessToken("token"))
+	srcRepos, err := modrank.FindRepositories(ctx, modrank.NewBitbucketWorkspaceSource(client, "ws"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := sourceRepositories(srcRepos)
+	if len(repos) != 2 {
+		t.Fatalf("unexpected repository number: %d", len(repos))
+	}
+	if repos[0].URL() != "https://bitbucket.org/ws/app.git" {
+		t.Fatalf("unexpected clone url: %s", repos[0].URL())
+	}
+	// the deleted repository doesn't stop prefetching other repositories.
+	deleted, err := repository.New("https://bitbucket.org/ws/deleted.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateRepositoryCache(ctx, append([]*repository.Repository{deleted}, repos...)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.IsArchived(ctx, deleted); !errors.Is(err, modrank.ErrRepositoryNotFound) {
+		t.Fatalf("expected not found error but got %v", err)
+	}
+	expected := []struct {
+		head        string
+		existsGoMod bool
+	}{
+		{head: "head", existsGoMod: true},
+		{head: "", existsGoMod: false},
+	}
+	for idx, repo := range repos {
+		head, err := client.GetHeadCommit(ctx, repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head != expected[idx].head {
+			t.Fatalf("unexpected head commit of %s: %s", repo.NameWithOwner(), head)
+		}
+		existsGoMod, err := client.ExistsGoMod(ctx, repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if existsGoMod != expected[idx].existsGoMod {
+			t.Fatalf("unexpected go.mod status of %s", repo.NameWithOwner())
+		}
+	}
+}
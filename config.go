@@ -1,16 +1,359 @@
 package modrank
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/goccy/go-yaml"
+
+	"github.com/goccy/go-modrank/repository"
 )
 
 type Config struct {
-	Database     string   `yaml:"database"`
-	Organization string   `yaml:"organization"`
+	Database     string           `yaml:"database"`
+	Organization string           `yaml:"organization"`
+	Repositories []string         `yaml:"repositories"`
+	ClonePath    string           `yaml:"clonePath"`
+	Sources      []*SourceConfig  `yaml:"sources"`
+	GitHub       *GitHubConfig    `yaml:"github"`
+	GitLab       *GitLabConfig    `yaml:"gitlab"`
+	Gitea        *GiteaConfig     `yaml:"gitea"`
+	Bitbucket    *BitbucketConfig `yaml:"bitbucket"`
+	GoProxy      *GoProxyConfig   `yaml:"goproxy"`
+	// HostedRepository is the configuration of the hosted repository resolution of Go modules.
+	HostedRepository *HostedRepositoryConfig `yaml:"hostedRepository"`
+	// Aliases is the configuration of the module aliases to merge the scores of moved or forked libraries.
+	Aliases *ModuleAliasConfig `yaml:"aliases"`
+}
+
+// ModuleAliasConfig is the configuration of the module aliases.
+//
+//	aliases:
+//	  builtin: true
+//	  mergeMajorVersions: true
+//	  modules:
+//	    - from: github.com/satori/go.uuid
+//	      to: github.com/gofrs/uuid
+//	    - from: github.com/example/old
+//	      to: github.com/example/new
+//	      link: true
+type ModuleAliasConfig struct {
+	// Builtin whether to use the built-in aliases of well-known moved or forked libraries.
+	Builtin bool `yaml:"builtin"`
+	// MergeMajorVersions whether to merge the major versions of the module such as /v2.
+	MergeMajorVersions bool `yaml:"mergeMajorVersions"`
+	// Modules is the list of the aliases.
+	Modules []*ModuleAliasEntryConfig `yaml:"modules"`
+}
+
+// ModuleAliasEntryConfig is the configuration of ModuleAlias.
+type ModuleAliasEntryConfig struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+	// Link if true, the scores are not merged and the old module is linked to the new one.
+	Link bool `yaml:"link"`
+}
+
+// Options returns the options of ModRank from the config.
+func (c *ModuleAliasConfig) Options() ([]Option, error) {
+	if c == nil {
+		return nil, nil
+	}
+	var opts []Option
+	for _, m := range c.Modules {
+		if m.From == "" || m.To == "" {
+			return nil, errors.New("required from and to of the module alias")
+		}
+		opts = append(opts, WithModuleAlias(&ModuleAlias{From: m.From, To: m.To, Link: m.Link}))
+	}
+	if c.Builtin {
+		opts = append(opts, WithBuiltinModuleAliases())
+	}
+	if c.MergeMajorVersions {
+		opts = append(opts, WithMergeMajorVersions())
+	}
+	return opts, nil
+}
+
+// HostedRepositoryConfig is the configuration of the hosted repository resolution of Go modules.
+//
+//	hostedRepository:
+//	  ttl: 168h
+//	  timeout: 10s
+//	  concurrency: 8
+//	  overrides:
+//	    example.com/mod: github.com/owner/mod
+type HostedRepositoryConfig struct {
+	// TTL is the period during which the resolved hosted repository stored in the database is used. Default is 168h.
+	TTL string `yaml:"ttl"`
+	// Timeout is the timeout of each strategy accessing the network to resolve one module. Default is 10s.
+	Timeout string `yaml:"timeout"`
+	// Concurrency is the max number of modules resolved concurrently by each strategy accessing the network. Default is 8.
+	Concurrency int `yaml:"concurrency"`
+	// Overrides is the mapping from the Go module path to the hosted repository specified manually.
+	Overrides map[string]string `yaml:"overrides"`
+}
+
+// Options returns the options of ModRank from the config.
+func (c *HostedRepositoryConfig) Options() ([]Option, error) {
+	if c == nil {
+		return nil, nil
+	}
+	var opts []Option
+	if c.TTL != "" {
+		ttl, err := time.ParseDuration(c.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hosted repository ttl %q: %w", c.TTL, err)
+		}
+		opts = append(opts, WithHostedRepositoryTTL(ttl))
+	}
+	if c.Timeout != "" {
+		timeout, err := time.ParseDuration(c.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hosted repository timeout %q: %w", c.Timeout, err)
+		}
+		opts = append(opts, WithHostedRepositoryTimeout(timeout))
+	}
+	if c.Concurrency > 0 {
+		opts = append(opts, WithHostedRepositoryConcurrency(c.Concurrency))
+	}
+	for modulePath, repo := range c.Overrides {
+		opts = append(opts, WithHostedRepositoryOverride(modulePath, repo))
+	}
+	return opts, nil
+}
+
+// GoProxyConfig is the configuration of the Go module proxies to resolve the hosted repository of Go modules.
+//
+//	goproxy:
+//	  url: https://athens.example.com|https://proxy.golang.org,direct
+//	  private: github.com/example/*
+//	  headers:
+//	    https://athens.example.com:
+//	      Authorization: Bearer xxx
+type GoProxyConfig struct {
+	// URL is the GOPROXY list. Default is the value of GOPROXY environment variable.
+	URL string `yaml:"url"`
+	// Private is the comma-separated glob patterns of private module paths like GOPRIVATE.
+	Private string `yaml:"private"`
+	// Headers is the headers sent to each proxy URL.
+	Headers map[string]map[string]string `yaml:"headers"`
+}
+
+// Options returns the options of ModRank from the config.
+func (c *GoProxyConfig) Options() []Option {
+	if c == nil {
+		return nil
+	}
+	var opts []Option
+	if c.URL != "" {
+		opts = append(opts, WithGoProxy(c.URL))
+	}
+	if c.Private != "" {
+		opts = append(opts, WithGoPrivate(c.Private))
+	}
+	for proxyURL, headers := range c.Headers {
+		header := make(http.Header)
+		for key, value := range headers {
+			header.Set(key, value)
+		}
+		opts = append(opts, WithGoProxyHeader(proxyURL, header))
+	}
+	return opts
+}
+
+// GitHubConfig is the configuration of GitHub.com or GitHub Enterprise Server.
+// The access token is specified by the GITHUB_TOKEN environment variable.
+type GitHubConfig struct {
+	// URL is the base URL of GitHub Enterprise Server. Default is GitHub.com.
+	URL string `yaml:"url"`
+	// Hosts is the list of additional host names treated as GitHub.
+	Hosts []string `yaml:"hosts"`
+	// Filter is the filter of repositories in the organization specified by the top-level organization.
+	Filter *GitHubFilterConfig `yaml:"filter"`
+}
+
+// GitHubFilterConfig is the configuration of GitHubRepositoryFilter.
+//
+//	filter:
+//	  topics: ["backend"]
+//	  languages: ["Go"]
+//	  excludeForks: true
+//	  visibility: private
+//	  pushedSince: 2160h
+type GitHubFilterConfig struct {
+	// Topics keeps repositories that have any of the topics.
+	Topics []string `yaml:"topics"`
+	// Languages keeps repositories whose primary language is any of the languages.
+	Languages []string `yaml:"languages"`
+	// ExcludeForks excludes forked repositories.
+	ExcludeForks bool `yaml:"excludeForks"`
+	// Visibility keeps repositories that have the visibility. One of public, private or internal.
+	Visibility string `yaml:"visibility"`
+	// PushedSince keeps repositories pushed after the date (e.g. 2025-01-01) or within the duration (e.g. 720h).
+	PushedSince string `yaml:"pushedSince"`
+}
+
+// GitHubRepositoryFilter creates GitHubRepositoryFilter from the config.
+func (c *GitHubFilterConfig) GitHubRepositoryFilter() (*GitHubRepositoryFilter, error) {
+	if c == nil {
+		return nil, nil
+	}
+	switch c.Visibility {
+	case "", "public", "private", "internal":
+	default:
+		return nil, fmt.Errorf("unexpected visibility %q: visibility must be public, private or internal", c.Visibility)
+	}
+	pushedSince, err := ParsePushedSince(c.PushedSince)
+	if err != nil {
+		return nil, err
+	}
+	return &GitHubRepositoryFilter{
+		Topics:       c.Topics,
+		Languages:    c.Languages,
+		ExcludeForks: c.ExcludeForks,
+		Visibility:   c.Visibility,
+		PushedSince:  pushedSince,
+	}, nil
+}
+
+// ParsePushedSince parses the date (e.g. 2025-01-01), RFC3339 time or duration from now (e.g. 720h).
+// Empty text returns zero time.
+func ParsePushedSince(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected pushed since format %q: specify date, RFC3339 time or duration", v)
+	}
+	return t, nil
+}
+
+// GitLabConfig is the configuration of the GitLab instance.
+// The access token is specified by the GITLAB_TOKEN environment variable.
+type GitLabConfig struct {
+	// URL is the base URL of the GitLab instance. Default is https://gitlab.com.
+	URL string `yaml:"url"`
+}
+
+// GiteaConfig is the configuration of the Gitea or Forgejo instance.
+// The access token is specified by the GITEA_TOKEN environment variable.
+type GiteaConfig struct {
+	// URL is the base URL of the Gitea instance.
+	URL string `yaml:"url"`
+}
+
+// BitbucketConfig is the configuration of Bitbucket Cloud.
+// The access token is specified by the BITBUCKET_TOKEN environment variable.
+type BitbucketConfig struct {
+	// URL is the base URL of Bitbucket Cloud REST API. Default is https://api.bitbucket.org/2.0.
+	URL string `yaml:"url"`
+}
+
+// SourceConfig is the configuration of RepositorySource.
+//
+//	sources:
+//	  - type: github
+//	    organization: goccy
+//	    filter:
+//	      languages: ["Go"]
+//	      excludeForks: true
+//	  - type: gitlab
+//	    group: gitlab-org/ci-cd
+//	  - type: gitea
+//	    organization: gitea
+//	  - type: bitbucket
+//	    workspace: atlassian
+//	  - type: static
+//	    repositories:
+//	      - github.com/goccy/go-yaml
+//	    weight: 10
+//	  - type: file
+//	    path: repositories.txt
+//	  - type: local
+//	    paths: ["./"]
+//	  - type: mirror
+//	    path: /srv/git
+type SourceConfig struct {
+	// Type is the source type. One of github, gitlab, gitea, bitbucket, static, file, local or mirror.
+	Type string `yaml:"type"`
+	// Organization is the organization name for github or gitea source.
+	// For github source, the user name is also accepted.
+	Organization string `yaml:"organization"`
+	// Group is the GitLab group path for gitlab source.
+	Group string `yaml:"group"`
+	// Workspace is the Bitbucket workspace name for bitbucket source.
+	Workspace string `yaml:"workspace"`
+	// Repositories is the list of repository addresses for static source.
 	Repositories []string `yaml:"repositories"`
-	ClonePath    string   `yaml:"clonePath"`
+	// Path is the file path for file source or the root directory for mirror source.
+	Path string `yaml:"path"`
+	// Paths is the list of directories for local source.
+	Paths []string `yaml:"paths"`
+	// Weight is the weight applied to all repositories discovered by the source.
+	Weight int `yaml:"weight"`
+	// Filter is the filter of repositories for github source.
+	Filter *GitHubFilterConfig `yaml:"filter"`
+}
+
+// SourceClients is the set of API clients used to create RepositorySource from SourceConfig.
+type SourceClients struct {
+	GitHub    *GitHubClient
+	GitLab    *GitLabClient
+	Gitea     *GiteaClient
+	Bitbucket *BitbucketClient
+}
+
+// RepositorySource creates RepositorySource from the config.
+func (c *SourceConfig) RepositorySource(clients *SourceClients, opts ...repository.Option) (RepositorySource, error) {
+	if c.Weight != 0 {
+		opts = append(append([]repository.Option{}, opts...), repository.WithWeight(c.Weight))
+	}
+	switch c.Type {
+	case "github":
+		if clients.GitHub == nil {
+			return nil, errors.New("GitHub client is required for github source")
+		}
+		filter, err := c.Filter.GitHubRepositoryFilter()
+		if err != nil {
+			return nil, err
+		}
+		return NewGitHubOwnerSource(clients.GitHub, c.Organization, filter, opts...), nil
+	case "gitlab":
+		if clients.GitLab == nil {
+			return nil, errors.New("GitLab client is required for gitlab source")
+		}
+		return NewGitLabGroupSource(clients.GitLab, c.Group, opts...), nil
+	case "gitea":
+		if clients.Gitea == nil {
+			return nil, errors.New("Gitea client is required for gitea source")
+		}
+		return NewGiteaOrganizationSource(clients.Gitea, c.Organization, opts...), nil
+	case "bitbucket":
+		if clients.Bitbucket == nil {
+			return nil, errors.New("Bitbucket client is required for bitbucket source")
+		}
+		return NewBitbucketWorkspaceSource(clients.Bitbucket, c.Workspace, opts...), nil
+	case "static":
+		return NewStaticSource(c.Repositories, opts...), nil
+	case "file":
+		return NewFileSource(c.Path, opts...), nil
+	case "local":
+		return NewLocalSource(c.Paths, opts...), nil
+	case "mirror":
+		return NewMirrorSource(c.Path, opts...), nil
+	}
+	return nil, fmt.Errorf("unknown repository source type: %q", c.Type)
 }
 
 func LoadConfig(path string) (*Config, error) {
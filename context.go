@@ -19,7 +19,12 @@ func withLogAttr(ctx context.Context, attrs ...any) context.Context {
 	return context.WithValue(ctx, loggerKey{}, logger.(*slog.Logger).With(attrs...))
 }
 
+// logger returns the logger in the context.
+// If the context doesn't have the logger such as when the API client is used directly, the default logger is returned.
 func logger(ctx context.Context) *slog.Logger {
 	logger := ctx.Value(loggerKey{})
+	if logger == nil {
+		return slog.Default()
+	}
 	return logger.(*slog.Logger)
 }
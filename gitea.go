@@ -0,0 +1,248 @@
+package modrank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/goccy/go-modrank/repository"
+)
+
+// giteaPageSize is the number of items per page. Gitea limits it to 50 by default.
+const giteaPageSize = 50
+
+type GiteaAccessToken = GitAccessToken
+
+func GiteaStaticAccessToken(tk string) *GiteaAccessToken {
+	return &GitAccessToken{
+		issuer: func(_ context.Context) (string, error) {
+			return tk, nil
+		},
+	}
+}
+
+var _ RepositoryHost = new(GiteaClient)
+
+// GiteaClient is the client of Gitea REST API. Forgejo is also supported because it provides the compatible API.
+// It implements RepositoryHost for repositories hosted by the Gitea instance.
+type GiteaClient struct {
+	httpClientHolder
+
+	baseURL          *url.URL
+	giteaAccessToken *GiteaAccessToken
+	repoCache        map[string]*GiteaRepository
+	repoCacheMu      sync.RWMutex
+}
+
+// GiteaRepository represents the Gitea repository.
+type GiteaRepository struct {
+	FullName      string `json:"full_name"`
+	CloneURL      string `json:"clone_url"`
+	Archived      bool   `json:"archived"`
+	Empty         bool   `json:"empty"`
+	DefaultBranch string `json:"default_branch"`
+	// HeadCommit is the head commit hash of the default branch. It's set by CreateRepositoryCache.
+	HeadCommit string `json:"-"`
+	// Err is the error of the repository returned by the API such as not found or forbidden. It's set by CreateRepositoryCache.
+	Err error `json:"-"`
+}
+
+// NewGiteaClient creates the client for the Gitea instance. e.g.) https://gitea.example.com
+// Repositories whose host is the same as the baseURL are handled by this client.
+func NewGiteaClient(ctx context.Context, baseURL string, token *GiteaAccessToken) (*GiteaClient, error) {
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("modrank: invalid Gitea URL %s: %w", baseURL, err)
+	}
+	if parsedURL.Host == "" {
+		return nil, fmt.Errorf("modrank: invalid Gitea URL %s", baseURL)
+	}
+	return &GiteaClient{
+		baseURL:          parsedURL,
+		giteaAccessToken: token,
+		repoCache:        make(map[string]*GiteaRepository),
+	}, nil
+}
+
+// Match reports whether the repository is hosted by this Gitea instance.
+func (c *GiteaClient) Match(repo *repository.Repository) bool {
+	return repo.Host() == repository.URLHost(c.baseURL)
+}
+
+// CloneAuth returns the Gitea access token of the client to clone repositories.
+// Gitea accepts the access token with any username, so the default username is used.
+func (c *GiteaClient) CloneAuth() (repository.TokenIssuer, string) {
+	if c.giteaAccessToken == nil {
+		return nil, ""
+	}
+	return repository.TokenIssuer(c.giteaAccessToken.issuer), ""
+}
+
+// FindRepositoriesByOrganization returns all repositories in the organization.
+// Archived repositories are also returned, so check GiteaRepository.Archived if you want to skip them.
+func (c *GiteaClient) FindRepositoriesByOrganization(ctx context.Context, org string) ([]*GiteaRepository, error) {
+	var repos []*GiteaRepository
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("limit", strconv.Itoa(giteaPageSize))
+		var pageRepos []*GiteaRepository
+		if err := c.get(ctx, path.Join("orgs", url.PathEscape(org), "repos"), query, &pageRepos); err != nil {
+			return nil, fmt.Errorf("failed to get repositories of %s organization: %w", org, err)
+		}
+		repos = append(repos, pageRepos...)
+		if len(pageRepos) < giteaPageSize {
+			break
+		}
+	}
+	return repos, nil
+}
+
+func (c *GiteaClient) IsArchived(ctx context.Context, repo *repository.Repository) (bool, error) {
+	giteaRepo := c.getRepositoryFromCache(repo.NameWithOwner())
+	if giteaRepo == nil {
+		return false, errors.New("cannot use IsArchived unless you create a cache in advance by CreateRepositoryCache")
+	}
+	if giteaRepo.Err != nil {
+		return false, giteaRepo.Err
+	}
+	return giteaRepo.Archived, nil
+}
+
+func (c *GiteaClient) GetHeadCommit(ctx context.Context, repo *repository.Repository) (string, error) {
+	giteaRepo := c.getRepositoryFromCache(repo.NameWithOwner())
+	if giteaRepo == nil {
+		return "", errors.New("cannot use GetHeadCommit unless you create a cache in advance by CreateRepositoryCache")
+	}
+	if giteaRepo.Err != nil {
+		return "", giteaRepo.Err
+	}
+	return giteaRepo.HeadCommit, nil
+}
+
+// ExistsGoMod returns whether go.mod exists in the tree of the head commit of the default branch.
+func (c *GiteaClient) ExistsGoMod(ctx context.Context, repo *repository.Repository) (bool, error) {
+	head, err := c.GetHeadCommit(ctx, repo)
+	if err != nil {
+		return false, err
+	}
+	if head == "" {
+		return false, nil
+	}
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("recursive", "true")
+		query.Set("page", strconv.Itoa(page))
+		var tree struct {
+			Tree []struct {
+				Path string `json:"path"`
+				Type string `json:"type"`
+			} `json:"tree"`
+			Truncated bool `json:"truncated"`
+		}
+		if err := c.get(ctx, path.Join("repos", url.PathEscape(repo.Owner()), url.PathEscape(repo.Name()), "git", "trees", head), query, &tree); err != nil {
+			if errors.Is(err, errAPINotFound) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to get tree from head commit of default branch: %w", err)
+		}
+		for _, entry := range tree.Tree {
+			if entry.Type == "blob" && path.Base(entry.Path) == "go.mod" {
+				return true, nil
+			}
+		}
+		if !tree.Truncated {
+			break
+		}
+	}
+	return false, nil
+}
+
+// CreateRepositoryCache prefetches the archived status and the head commit of the default branch of the repositories.
+func (c *GiteaClient) CreateRepositoryCache(ctx context.Context, repos []*repository.Repository) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(hostAPIConcurrency)
+	for _, repo := range repos {
+		if !c.Match(repo) {
+			continue
+		}
+		eg.Go(func() error {
+			giteaRepo, err := c.getRepository(ctx, repo.Owner(), repo.Name())
+			if err != nil {
+				repoErr := newRepositoryError(repo.NameWithOwner(), err)
+				if repoErr == nil {
+					return err
+				}
+				// the repository is deleted or the token has no permission to access it.
+				giteaRepo = &GiteaRepository{FullName: repo.NameWithOwner(), Err: repoErr}
+			}
+			c.setRepositoryCache(repo.NameWithOwner(), giteaRepo)
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+func (c *GiteaClient) getRepository(ctx context.Context, owner, name string) (*GiteaRepository, error) {
+	repoPath := path.Join("repos", url.PathEscape(owner), url.PathEscape(name))
+	var giteaRepo GiteaRepository
+	if err := c.get(ctx, repoPath, nil, &giteaRepo); err != nil {
+		return nil, fmt.Errorf("failed to get %s/%s repository: %w", owner, name, err)
+	}
+	if giteaRepo.Empty || giteaRepo.DefaultBranch == "" {
+		return &giteaRepo, nil
+	}
+	var branch struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	if err := c.get(ctx, path.Join(repoPath, "branches", url.PathEscape(giteaRepo.DefaultBranch)), nil, &branch); err != nil {
+		return nil, fmt.Errorf("failed to get default branch of %s/%s repository: %w", owner, name, err)
+	}
+	giteaRepo.HeadCommit = branch.Commit.ID
+	return &giteaRepo, nil
+}
+
+// get calls GET method of Gitea REST API and decodes the response to v.
+func (c *GiteaClient) get(ctx context.Context, apiPath string, query url.Values, v any) error {
+	reqURL := c.baseURL.JoinPath("api", "v1")
+	// apiPath contains escaped path segments, so set it as the raw path.
+	reqURL.RawPath = reqURL.EscapedPath() + "/" + apiPath
+	reqURL.Path, _ = url.PathUnescape(reqURL.RawPath)
+	reqURL.RawQuery = query.Encode()
+
+	var authorization string
+	if c.giteaAccessToken != nil {
+		tk, err := c.giteaAccessToken.issuer(ctx)
+		if err != nil {
+			return fmt.Errorf("modrank: failed to issue Gitea API access token: %w", err)
+		}
+		if tk != "" {
+			authorization = "token " + tk
+		}
+	}
+	if _, err := getJSON(ctx, c.client(), reqURL.String(), authorization, v); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *GiteaClient) getRepositoryFromCache(nameWithOwner string) *GiteaRepository {
+	c.repoCacheMu.RLock()
+	repo := c.repoCache[nameWithOwner]
+	c.repoCacheMu.RUnlock()
+	return repo
+}
+
+func (c *GiteaClient) setRepositoryCache(nameWithOwner string, repo *GiteaRepository) {
+	c.repoCacheMu.Lock()
+	c.repoCache[nameWithOwner] = repo
+	c.repoCacheMu.Unlock()
+}
@@ -0,0 +1,86 @@
+package modrank_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-modrank"
+	"github.com/goccy/go-modrank/repository"
+)
+
+func TestGiteaClient(t *testing.T) {
+	ctx := context.Background()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "token token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var v any
+		switch req.URL.EscapedPath() {
+		case "/api/v1/orgs/org/repos":
+			v = []map[string]any{}
+			if req.URL.Query().Get("page") == "1" {
+				v = []map[string]any{
+					{"full_name": "org/app", "clone_url": server.URL + "/org/app.git", "default_branch": "main"},
+					{"full_name": "org/old", "clone_url": server.URL + "/org/old.git", "archived": true},
+				}
+			}
+		case "/api/v1/repos/org/app":
+			v = map[string]any{"full_name": "org/app", "default_branch": "main"}
+		case "/api/v1/repos/org/app/branches/main":
+			v = map[string]any{"commit": map[string]any{"id": "head"}}
+		case "/api/v1/repos/org/app/git/trees/head":
+			v = map[string]any{"tree": []map[string]any{{"path": "sub/go.mod", "type": "blob"}}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	defer server.Close()
+
+	client, err := modrank.NewGiteaClient(ctx, server.URL, modrank.GiteaStaticAccessToken("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcRepos, err := modrank.FindRepositories(ctx, modrank.NewGiteaOrganizationSource(client, "org"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := sourceRepositories(srcRepos)
+	if len(repos) != 1 {
+		t.Fatalf("archived repository must be excluded: %d", len(repos))
+	}
+	// the deleted repository doesn't stop prefetching other repositories.
+	deleted, err := repository.New(server.URL + "/org/deleted.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateRepositoryCache(ctx, append([]*repository.Repository{deleted}, repos...)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetHeadCommit(ctx, deleted); !errors.Is(err, modrank.ErrRepositoryNotFound) {
+		t.Fatalf("expected not found error but got %v", err)
+	}
+	head, err := client.GetHeadCommit(ctx, repos[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != "head" {
+		t.Fatalf("unexpected head commit: %s", head)
+	}
+	existsGoMod, err := client.ExistsGoMod(ctx, repos[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !existsGoMod {
+		t.Fatal("failed to find go.mod")
+	}
+}
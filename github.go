@@ -8,90 +8,437 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/go-github/v70/github"
 	"github.com/shurcooL/githubv4"
-	"golang.org/x/oauth2"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/goccy/go-modrank/repository"
 )
 
+// defaultGitHubHost is the host name of GitHub.com.
+const defaultGitHubHost = "github.com"
+
 type GitHubClient struct {
-	githubAccessToken *GitHubAccessToken
-	repoCache         map[string]*GitHubRepository
-	repoCacheMu       sync.RWMutex
+	httpClientHolder
+
+	baseURL     string
+	hosts       []string
+	tokenPool   []*GitHubAccessToken
+	rateLimiter *githubRateLimiter
+	repoCache   map[string]*GitHubRepository
+	repoCacheMu sync.RWMutex
+
+	// restClient is created at the first use and shared by ExistsGoMod calls.
+	restClient     *github.Client
+	restClientErr  error
+	restClientOnce sync.Once
+}
+
+type GitHubClientOption func(*GitHubClient)
+
+// GitHubBaseURL specify the base URL of GitHub Enterprise Server. e.g.) https://github.example.com
+// The REST API and GraphQL API are accessed by {baseURL}/api/v3 and {baseURL}/api/graphql,
+// and repositories whose host is the same as the baseURL are treated as GitHub repositories.
+func GitHubBaseURL(baseURL string) GitHubClientOption {
+	return func(c *GitHubClient) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// GitHubHosts specify additional host names of repositories served by the GitHub API.
+// e.g.) the host name for SSH access that differs from the base URL.
+func GitHubHosts(hosts ...string) GitHubClientOption {
+	return func(c *GitHubClient) {
+		c.hosts = append(c.hosts, hosts...)
+	}
+}
+
+// GitHubTokenPool specify the tokens used in rotation instead of the token passed to NewGitHubClient.
+// The token with the most remaining rate limit budget is used for each request.
+func GitHubTokenPool(tokens ...*GitHubAccessToken) GitHubClientOption {
+	return func(c *GitHubClient) {
+		c.tokenPool = append(c.tokenPool, tokens...)
+	}
 }
 
 type GitHubRepository struct {
 	Repository *repository.Repository
-	IsArchived bool
-	HeadCommit string
+	// NameWithOwner is the canonical name of the repository. e.g.) goccy/go-modrank
+	// If the repository has been renamed or transferred, it differs from the name of Repository.
+	NameWithOwner string
+	IsArchived    bool
+	HeadCommit    string
+	// Err is the error of the repository returned by the GraphQL API such as NOT_FOUND or FORBIDDEN.
+	Err error
+}
+
+// NewGitHubClient creates the client for GitHub.com by default.
+// To use GitHub Enterprise Server, specify GitHubBaseURL option.
+func NewGitHubClient(ctx context.Context, token *GitHubAccessToken, opts ...GitHubClientOption) *GitHubClient {
+	c := &GitHubClient{
+		repoCache: make(map[string]*GitHubRepository),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.hosts = append([]string{c.Host()}, c.hosts...)
+	if len(c.tokenPool) == 0 {
+		c.tokenPool = []*GitHubAccessToken{token}
+	}
+	c.rateLimiter = newGitHubRateLimiter(c.tokenPool)
+	return c
+}
+
+// RateLimits returns the latest rate limit state of each token and resource observed by the client.
+func (c *GitHubClient) RateLimits() []*GitHubRateLimit {
+	return c.rateLimiter.rateLimits()
+}
+
+// GraphQLCost returns the total cost of GraphQL queries to prefetch repository status.
+func (c *GitHubClient) GraphQLCost() int {
+	return c.rateLimiter.totalGraphQLCost()
+}
+
+// httpClient returns the client to send the request of the rate limit resource.
+// The client sets the access token and waits for the rate limit.
+func (c *GitHubClient) httpClient(resource string) *http.Client {
+	hc := *c.client()
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	hc.Transport = &githubTransport{
+		limiter:  c.rateLimiter,
+		resource: resource,
+		base:     base,
+	}
+	return &hc
+}
+
+// Host returns the host of the web site of GitHub including the port if specified. e.g.) github.com
+func (c *GitHubClient) Host() string {
+	if c.baseURL == "" {
+		return defaultGitHubHost
+	}
+	parsedURL, err := url.Parse(c.baseURL)
+	if err != nil || parsedURL.Hostname() == "" {
+		return defaultGitHubHost
+	}
+	return repository.URLHost(parsedURL)
+}
+
+// Hosts returns all host names of repositories served by the GitHub API.
+func (c *GitHubClient) Hosts() []string {
+	return c.hosts
+}
+
+// CloneAuth returns the access token of the client to clone GitHub repositories.
+func (c *GitHubClient) CloneAuth() (repository.TokenIssuer, string) {
+	if len(c.tokenPool) == 0 || c.tokenPool[0] == nil {
+		return nil, ""
+	}
+	return repository.TokenIssuer(c.tokenPool[0].issuer), ""
+}
+
+// Match reports whether the repository is served by the GitHub API.
+func (c *GitHubClient) Match(repo *repository.Repository) bool {
+	for _, host := range c.hosts {
+		if repo.Host() == host {
+			return true
+		}
+	}
+	return false
+}
+
+// RepositoryURL returns the url to clone the repository.
+func (c *GitHubClient) RepositoryURL(owner, repo string) string {
+	return fmt.Sprintf("https://%s/%s/%s.git", c.Host(), owner, repo)
+}
+
+func (c *GitHubClient) graphQLURL() string {
+	if c.baseURL == "" {
+		return "https://api.github.com/graphql"
+	}
+	return c.baseURL + "/api/graphql"
+}
+
+func (c *GitHubClient) newGraphQLClient(httpClient *http.Client) *githubv4.Client {
+	if c.baseURL == "" {
+		return githubv4.NewClient(httpClient)
+	}
+	return githubv4.NewEnterpriseClient(c.graphQLURL(), httpClient)
+}
+
+func (c *GitHubClient) newRESTClient(httpClient *http.Client) (*github.Client, error) {
+	client := github.NewClient(httpClient)
+	if c.baseURL == "" {
+		return client, nil
+	}
+	return client.WithEnterpriseURLs(c.baseURL, c.baseURL)
+}
+
+// coreRESTClient returns the REST API client for the core rate limit resource.
+// The HTTP client must be set by SetHTTPClient before the first call.
+func (c *GitHubClient) coreRESTClient() (*github.Client, error) {
+	c.restClientOnce.Do(func() {
+		c.restClient, c.restClientErr = c.newRESTClient(c.httpClient(githubRateLimitResourceCore))
+	})
+	return c.restClient, c.restClientErr
+}
+
+// GitHubOwnerRepository is the repository found by FindRepositoriesByOwner with its metadata.
+type GitHubOwnerRepository struct {
+	Name            string
+	NameWithOwner   string
+	URL             string
+	DefaultBranch   string
+	IsArchived      bool
+	IsFork          bool
+	Visibility      string
+	PrimaryLanguage string
+	Topics          []string
+	Stars           int
+	PushedAt        time.Time
+}
+
+// GitHubRepositoryFilter is the filter of repositories found by FindRepositoriesByOwner.
+// Zero value doesn't filter any repositories.
+type GitHubRepositoryFilter struct {
+	// Topics keeps repositories that have any of the topics.
+	Topics []string
+	// Languages keeps repositories whose primary language is any of the languages. e.g.) Go
+	// The comparison is case-insensitive.
+	Languages []string
+	// ExcludeForks excludes forked repositories.
+	ExcludeForks bool
+	// Visibility keeps repositories that have the visibility. One of public, private or internal.
+	Visibility string
+	// PushedSince keeps repositories pushed after the time.
+	PushedSince time.Time
+}
+
+// searchable reports whether the filter has the conditions applied by the search API.
+func (f *GitHubRepositoryFilter) searchable() bool {
+	if f == nil {
+		return false
+	}
+	return len(f.Topics) != 0 || len(f.Languages) != 0 || f.Visibility != "" || !f.PushedSince.IsZero()
+}
+
+// searchQueries returns the queries of the search API for the filter.
+// The search API combines the qualifiers by AND, so the query is created for each pair of the topic and the language
+// to keep repositories that have any of the topics and any of the languages.
+func (f *GitHubRepositoryFilter) searchQueries(ownerQualifier, owner string) []string {
+	base := []string{fmt.Sprintf("%s:%s", ownerQualifier, owner), "archived:false"}
+	if f.ExcludeForks {
+		base = append(base, "fork:false")
+	} else {
+		// forks are excluded from the search result by default.
+		base = append(base, "fork:true")
+	}
+	if f.Visibility != "" {
+		base = append(base, "is:"+strings.ToLower(f.Visibility))
+	}
+	if !f.PushedSince.IsZero() {
+		base = append(base, "pushed:>="+f.PushedSince.UTC().Format(time.RFC3339))
+	}
+	topics := f.Topics
+	if len(topics) == 0 {
+		topics = []string{""}
+	}
+	languages := f.Languages
+	if len(languages) == 0 {
+		languages = []string{""}
+	}
+	var queries []string
+	for _, topic := range topics {
+		for _, lang := range languages {
+			qualifiers := slices.Clone(base)
+			if topic != "" {
+				qualifiers = append(qualifiers, "topic:"+searchQualifierValue(topic))
+			}
+			if lang != "" {
+				qualifiers = append(qualifiers, "language:"+searchQualifierValue(lang))
+			}
+			queries = append(queries, strings.Join(qualifiers, " "))
+		}
+	}
+	return queries
+}
+
+func searchQualifierValue(v string) string {
+	if strings.Contains(v, " ") {
+		return fmt.Sprintf("%q", v)
+	}
+	return v
+}
+
+// githubRepositoryNode is the repository fields fetched by FindRepositoriesByOwner.
+type githubRepositoryNode struct {
+	Name             string
+	NameWithOwner    string
+	URL              string
+	IsArchived       bool
+	IsFork           bool
+	Visibility       string
+	StargazerCount   int
+	PushedAt         *time.Time
+	DefaultBranchRef struct {
+		Name string
+	}
+	PrimaryLanguage struct {
+		Name string
+	}
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string
+			}
+		}
+	} `graphql:"repositoryTopics(first: 20)"`
 }
 
-func NewGitHubClient(ctx context.Context, token *GitHubAccessToken) *GitHubClient {
-	return &GitHubClient{
-		githubAccessToken: token,
-		repoCache:         make(map[string]*GitHubRepository),
+func (n *githubRepositoryNode) ownerRepository() *GitHubOwnerRepository {
+	var topics []string
+	for _, topic := range n.RepositoryTopics.Nodes {
+		topics = append(topics, topic.Topic.Name)
+	}
+	var pushedAt time.Time
+	if n.PushedAt != nil {
+		pushedAt = *n.PushedAt
+	}
+	return &GitHubOwnerRepository{
+		Name:            n.Name,
+		NameWithOwner:   n.NameWithOwner,
+		URL:             n.URL,
+		DefaultBranch:   n.DefaultBranchRef.Name,
+		IsArchived:      n.IsArchived,
+		IsFork:          n.IsFork,
+		Visibility:      strings.ToLower(n.Visibility),
+		PrimaryLanguage: n.PrimaryLanguage.Name,
+		Topics:          topics,
+		Stars:           n.StargazerCount,
+		PushedAt:        pushedAt,
 	}
 }
 
-func (c *GitHubClient) FindRepositoriesByOwner(ctx context.Context, owner string) ([]string, error) {
-	var repoNames []string
-	var cursor *githubv4.String
+// FindRepositoriesByOwner finds repositories owned by the organization or user that match the filter.
+// If the filter has topics, languages, visibility or pushed since, the repositories are found by the search API,
+// so archived repositories are excluded and at most 1000 repositories are found for each pair of the topic and the language.
+// Otherwise, all repositories are listed and archived repositories are also returned with IsArchived flag.
+func (c *GitHubClient) FindRepositoriesByOwner(ctx context.Context, owner string, filter *GitHubRepositoryFilter) ([]*GitHubOwnerRepository, error) {
+	if filter.searchable() {
+		return c.searchRepositoriesByOwner(ctx, owner, filter)
+	}
+	return c.listRepositoriesByOwner(ctx, owner, filter)
+}
 
+func (c *GitHubClient) listRepositoriesByOwner(ctx context.Context, owner string, filter *GitHubRepositoryFilter) ([]*GitHubOwnerRepository, error) {
+	var (
+		repos  []*GitHubOwnerRepository
+		cursor *githubv4.String
+		isFork *githubv4.Boolean
+	)
+	if filter != nil && filter.ExcludeForks {
+		isFork = githubv4.NewBoolean(false)
+	}
 	for {
 		var query struct {
-			Organization struct {
+			RepositoryOwner struct {
 				Repositories struct {
-					Nodes []struct {
-						Name       string
-						IsArchived bool
-					}
+					Nodes    []githubRepositoryNode
 					PageInfo struct {
 						HasNextPage bool
 						EndCursor   *githubv4.String
 					}
-				} `graphql:"repositories(first: 100, after: $cursor)"`
-			} `graphql:"organization(login: $organization)"`
+				} `graphql:"repositories(first: 100, after: $cursor, isFork: $isFork, ownerAffiliations: [OWNER], orderBy: {field: NAME, direction: ASC})"`
+			} `graphql:"repositoryOwner(login: $owner)"`
 		}
-
 		variables := map[string]interface{}{
-			"organization": githubv4.String(owner),
-			"cursor":       cursor,
-		}
-
-		tk, err := c.githubAccessToken.issuer(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("modrank: failed to issue GitHub API access token: %w", err)
+			"owner":  githubv4.String(owner),
+			"cursor": cursor,
+			"isFork": isFork,
 		}
-		gqlClient := githubv4.NewClient(
-			oauth2.NewClient(
-				ctx,
-				oauth2.StaticTokenSource(&oauth2.Token{
-					AccessToken: tk,
-				}),
-			),
-		)
+		gqlClient := c.newGraphQLClient(c.httpClient(githubRateLimitResourceGraphQL))
 		if err := gqlClient.Query(ctx, &query, variables); err != nil {
 			return nil, err
 		}
-
-		for _, node := range query.Organization.Repositories.Nodes {
-			if node.IsArchived {
-				continue
-			}
-			repoNames = append(repoNames, node.Name)
+		for _, node := range query.RepositoryOwner.Repositories.Nodes {
+			repos = append(repos, node.ownerRepository())
 		}
-		if !query.Organization.Repositories.PageInfo.HasNextPage {
+		if !query.RepositoryOwner.Repositories.PageInfo.HasNextPage {
 			break
 		}
-		cursor = query.Organization.Repositories.PageInfo.EndCursor
+		cursor = query.RepositoryOwner.Repositories.PageInfo.EndCursor
+	}
+	return repos, nil
+}
+
+func (c *GitHubClient) searchRepositoriesByOwner(ctx context.Context, owner string, filter *GitHubRepositoryFilter) ([]*GitHubOwnerRepository, error) {
+	gqlClient := c.newGraphQLClient(c.httpClient(githubRateLimitResourceGraphQL))
+	var ownerQuery struct {
+		RepositoryOwner struct {
+			Typename string `graphql:"__typename"`
+		} `graphql:"repositoryOwner(login: $owner)"`
+	}
+	if err := gqlClient.Query(ctx, &ownerQuery, map[string]interface{}{"owner": githubv4.String(owner)}); err != nil {
+		return nil, err
+	}
+	ownerQualifier := "user"
+	if ownerQuery.RepositoryOwner.Typename == "Organization" {
+		ownerQualifier = "org"
+	}
+
+	var (
+		repos   []*GitHubOwnerRepository
+		seenMap = make(map[string]struct{})
+	)
+	for _, q := range filter.searchQueries(ownerQualifier, owner) {
+		var cursor *githubv4.String
+		for {
+			var query struct {
+				Search struct {
+					Nodes []struct {
+						Repository githubRepositoryNode `graphql:"... on Repository"`
+					}
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   *githubv4.String
+					}
+				} `graphql:"search(query: $query, type: REPOSITORY, first: 100, after: $cursor)"`
+			}
+			variables := map[string]interface{}{
+				"query":  githubv4.String(q),
+				"cursor": cursor,
+			}
+			if err := gqlClient.Query(ctx, &query, variables); err != nil {
+				return nil, err
+			}
+			for _, node := range query.Search.Nodes {
+				if _, exists := seenMap[node.Repository.NameWithOwner]; exists {
+					continue
+				}
+				seenMap[node.Repository.NameWithOwner] = struct{}{}
+				repos = append(repos, node.Repository.ownerRepository())
+			}
+			if !query.Search.PageInfo.HasNextPage {
+				break
+			}
+			cursor = query.Search.PageInfo.EndCursor
+		}
 	}
-	return repoNames, nil
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].NameWithOwner < repos[j].NameWithOwner
+	})
+	return repos, nil
 }
 
 func (c *GitHubClient) IsArchived(ctx context.Context, owner, repo string) (bool, error) {
@@ -100,6 +447,9 @@ func (c *GitHubClient) IsArchived(ctx context.Context, owner, repo string) (bool
 	if githubRepo == nil {
 		return false, errors.New("cannot use IsArchived unless you create a cache in advance by CreateGitHubRepositoryCache")
 	}
+	if githubRepo.Err != nil {
+		return false, githubRepo.Err
+	}
 	return githubRepo.IsArchived, nil
 }
 
@@ -109,9 +459,26 @@ func (c *GitHubClient) GetHeadCommit(ctx context.Context, owner, repo string) (s
 	if githubRepo == nil {
 		return "", errors.New("cannot use GetHeadCommit unless you create a cache in advance by CreateGitHubRepositoryCache")
 	}
+	if githubRepo.Err != nil {
+		return "", githubRepo.Err
+	}
 	return githubRepo.HeadCommit, nil
 }
 
+// CanonicalName returns the current owner and name of the renamed or transferred repository.
+// If the repository is not renamed or the cache is not created, returns the specified owner and name.
+func (c *GitHubClient) CanonicalName(owner, repo string) (string, string) {
+	githubRepo := c.getRepositoryFromCache(fmt.Sprintf("%s/%s", owner, repo))
+	if githubRepo == nil || githubRepo.NameWithOwner == "" {
+		return owner, repo
+	}
+	canonicalOwner, canonicalRepo, found := strings.Cut(githubRepo.NameWithOwner, "/")
+	if !found {
+		return owner, repo
+	}
+	return canonicalOwner, canonicalRepo
+}
+
 func (c *GitHubClient) ExistsGoMod(ctx context.Context, owner, repo string) (bool, error) {
 	head, err := c.GetHeadCommit(ctx, owner, repo)
 	if err != nil {
@@ -120,18 +487,11 @@ func (c *GitHubClient) ExistsGoMod(ctx context.Context, owner, repo string) (boo
 	if head == "" {
 		return false, nil
 	}
-	tk, err := c.githubAccessToken.issuer(ctx)
+	restClient, err := c.coreRESTClient()
 	if err != nil {
-		return false, fmt.Errorf("modrank: failed to issue GitHub API access token: %w", err)
-	}
-	restClient := github.NewClient(
-		oauth2.NewClient(
-			ctx,
-			oauth2.StaticTokenSource(&oauth2.Token{
-				AccessToken: tk,
-			}),
-		),
-	)
+		return false, err
+	}
+	owner, repo = c.CanonicalName(owner, repo)
 	tree, _, err := restClient.Git.GetTree(ctx, owner, repo, head, true)
 	if err != nil {
 		errRes, ok := err.(*github.ErrorResponse)
@@ -153,7 +513,7 @@ func (c *GitHubClient) ExistsGoMod(ctx context.Context, owner, repo string) (boo
 func (c *GitHubClient) CreateGitHubRepositoryCache(ctx context.Context, repos []*repository.Repository) error {
 	githubRepos := make([]*repository.Repository, 0, len(repos))
 	for _, repo := range repos {
-		if !repo.IsGitHubRepository() {
+		if !c.Match(repo) {
 			continue
 		}
 		githubRepos = append(githubRepos, repo)
@@ -168,21 +528,17 @@ func (c *GitHubClient) CreateGitHubRepositoryCache(ctx context.Context, repos []
 }
 
 func (c *GitHubClient) createGitHubRepositoryCache(ctx context.Context, repos []*repository.Repository) error {
-	const githubAPI = "https://api.github.com/graphql"
-
 	var (
 		queries  []string
 		queryMap = make(map[string]*repository.Repository)
 	)
-	for _, repo := range repos {
-		nameWithOwner := repo.NameWithOwner()
-		key := strings.ReplaceAll(nameWithOwner, "/", "_")
-		key = strings.ReplaceAll(key, "-", "_")
-		key = strings.ReplaceAll(key, ".", "_")
+	for idx, repo := range repos {
+		// use the index as the alias because the repository name can't be used as the GraphQL alias as is.
+		key := fmt.Sprintf("repo%d", idx)
 		queryMap[key] = repo
 		queries = append(queries, fmt.Sprintf(`
   %s: repository(owner: "%s", name: "%s") {
-    name
+    nameWithOwner
     isArchived
     defaultBranchRef {
       target {
@@ -196,6 +552,9 @@ func (c *GitHubClient) createGitHubRepositoryCache(ctx context.Context, repos []
 
 	query := fmt.Sprintf(`
 query {
+  rateLimit {
+    cost
+  }
   %s
 }`, strings.Join(queries, "\n"))
 
@@ -205,18 +564,13 @@ query {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", githubAPI, bytes.NewBuffer(gqlBodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.graphQLURL(), bytes.NewBuffer(gqlBodyBytes))
 	if err != nil {
 		return err
 	}
-	tk, err := c.githubAccessToken.issuer(ctx)
-	if err != nil {
-		return fmt.Errorf("modrank: failed to issue GitHub API access token: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+tk)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.httpClient(githubRateLimitResourceGraphQL).Do(req)
 	if err != nil {
 		return err
 	}
@@ -228,33 +582,84 @@ query {
 	}
 
 	var result struct {
-		Data map[string]struct {
-			Name             string `json:"name"`
+		Data   map[string]json.RawMessage `json:"data"`
+		Errors []*githubGraphQLError      `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	// The errors are reported for each alias of the repository, so one bad repository doesn't fail others.
+	repoErrMap := make(map[string]*githubGraphQLError)
+	for _, gqlErr := range result.Errors {
+		if len(gqlErr.Path) == 0 {
+			return fmt.Errorf("failed to GitHub API: %s", gqlErr.Message)
+		}
+		key, _ := gqlErr.Path[0].(string)
+		if _, exists := queryMap[key]; !exists {
+			return fmt.Errorf("failed to GitHub API: %s", gqlErr.Message)
+		}
+		repoErrMap[key] = gqlErr
+	}
+	if data, exists := result.Data["rateLimit"]; exists {
+		var rateLimit struct {
+			Cost int `json:"cost"`
+		}
+		if err := json.Unmarshal(data, &rateLimit); err != nil {
+			return err
+		}
+		c.rateLimiter.addGraphQLCost(rateLimit.Cost)
+	}
+	for key, repo := range queryMap {
+		var stat *struct {
+			NameWithOwner    string `json:"nameWithOwner"`
 			IsArchived       bool   `json:"isArchived"`
 			DefaultBranchRef struct {
 				Target struct {
 					OID string `json:"oid"`
 				} `json:"target"`
 			} `json:"defaultBranchRef"`
-		} `json:"data"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return err
-	}
-	for key, stat := range result.Data {
-		repo, exists := queryMap[key]
-		if !exists {
-			return fmt.Errorf("failed to find repository from %s", key)
+		}
+		if data, exists := result.Data[key]; exists {
+			if err := json.Unmarshal(data, &stat); err != nil {
+				return err
+			}
+		}
+		if stat == nil {
+			// the repository is deleted or the token has no permission to access it.
+			repoErr := &RepositoryError{NameWithOwner: repo.NameWithOwner(), Type: githubNotFoundErrorType, Message: "repository is not found"}
+			if gqlErr, exists := repoErrMap[key]; exists {
+				repoErr.Type = gqlErr.Type
+				repoErr.Message = gqlErr.Message
+			}
+			c.setRepositoryCache(&GitHubRepository{
+				Repository:    repo,
+				NameWithOwner: repo.NameWithOwner(),
+				Err:           repoErr,
+			})
+			continue
+		}
+		if stat.NameWithOwner != "" && !strings.EqualFold(stat.NameWithOwner, repo.NameWithOwner()) {
+			logger(ctx).DebugContext(ctx, "repository has been renamed", "repo", repo.NameWithOwner(), "canonical", stat.NameWithOwner)
 		}
 		c.setRepositoryCache(&GitHubRepository{
-			Repository: repo,
-			IsArchived: stat.IsArchived,
-			HeadCommit: stat.DefaultBranchRef.Target.OID,
+			Repository:    repo,
+			NameWithOwner: stat.NameWithOwner,
+			IsArchived:    stat.IsArchived,
+			HeadCommit:    stat.DefaultBranchRef.Target.OID,
 		})
 	}
 	return nil
 }
 
+// githubNotFoundErrorType is the error type of GraphQL API for the repository that doesn't exist.
+const githubNotFoundErrorType = repositoryNotFoundErrorType
+
+type githubGraphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Path    []any  `json:"path"`
+}
+
 const chunkSize = 100
 
 func (c *GitHubClient) chunkRepos(repos []*repository.Repository) [][]*repository.Repository {
@@ -0,0 +1,270 @@
+package modrank
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	githubRateLimitResourceCore    = "core"
+	githubRateLimitResourceGraphQL = "graphql"
+
+	// githubMaxRetry is the max number of retries for the request rejected by the rate limit.
+	githubMaxRetry = 5
+	// githubSecondaryRateLimitWait is the waiting time for the secondary rate limit without Retry-After header.
+	githubSecondaryRateLimitWait = time.Minute
+	// githubThrottleRatio is the ratio of the remaining budget to start throttling.
+	// Under this ratio, the requests are spread evenly until the rate limit is reset.
+	githubThrottleRatio = 0.1
+)
+
+// GitHubRateLimit is the rate limit state of the GitHub API for each token and resource.
+type GitHubRateLimit struct {
+	// Token is the index of the token in the token pool.
+	Token int
+	// Resource is the rate limit resource. e.g.) core, graphql
+	Resource  string
+	Limit     int
+	Remaining int
+	Used      int
+	Reset     time.Time
+}
+
+type githubTokenState struct {
+	index  int
+	token  *GitHubAccessToken
+	limits map[string]*GitHubRateLimit
+}
+
+// githubRateLimiter selects the token that has the most remaining budget from the token pool,
+// and waits until the rate limit is reset if all tokens are exhausted.
+type githubRateLimiter struct {
+	tokens      []*githubTokenState
+	graphqlCost int
+	mu          sync.Mutex
+}
+
+func newGitHubRateLimiter(tokens []*GitHubAccessToken) *githubRateLimiter {
+	states := make([]*githubTokenState, 0, len(tokens))
+	for idx, token := range tokens {
+		states = append(states, &githubTokenState{
+			index:  idx,
+			token:  token,
+			limits: make(map[string]*GitHubRateLimit),
+		})
+	}
+	return &githubRateLimiter{tokens: states}
+}
+
+// acquire returns the token to send the request of the resource.
+func (l *githubRateLimiter) acquire(ctx context.Context, resource string) (*githubTokenState, error) {
+	for {
+		state, wait, throttle := l.selectToken(resource)
+		if state != nil {
+			if throttle > 0 {
+				logger(ctx).DebugContext(ctx, "throttle GitHub API request", "token", state.index, "resource", resource, "wait", throttle)
+				if err := sleepContext(ctx, throttle); err != nil {
+					return nil, err
+				}
+			}
+			return state, nil
+		}
+		logger(ctx).WarnContext(ctx, "waiting for GitHub API rate limit reset", "resource", resource, "wait", wait)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (l *githubRateLimiter) selectToken(resource string) (*githubTokenState, time.Duration, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var (
+		selected  *githubTokenState
+		selLimit  *GitHubRateLimit
+		nextReset time.Time
+	)
+	for _, state := range l.tokens {
+		limit := state.limits[resource]
+		if limit == nil || !now.Before(limit.Reset) {
+			// unknown or already reset.
+			return state, 0, 0
+		}
+		if limit.Remaining <= 0 {
+			if nextReset.IsZero() || limit.Reset.Before(nextReset) {
+				nextReset = limit.Reset
+			}
+			continue
+		}
+		if selLimit == nil || selLimit.Remaining < limit.Remaining {
+			selected = state
+			selLimit = limit
+		}
+	}
+	if selected == nil {
+		return nil, nextReset.Sub(now) + time.Second, 0
+	}
+	// reserve the budget for concurrent requests until the response updates it.
+	selLimit.Remaining--
+	var throttle time.Duration
+	if float64(selLimit.Remaining) < float64(selLimit.Limit)*githubThrottleRatio {
+		throttle = selLimit.Reset.Sub(now) / time.Duration(selLimit.Remaining+1)
+	}
+	return selected, 0, throttle
+}
+
+// update updates the rate limit state of the token by X-RateLimit-* response headers.
+func (l *githubRateLimiter) update(state *githubTokenState, resource string, header http.Header) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	used, _ := strconv.Atoi(header.Get("X-RateLimit-Used"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if v := header.Get("X-RateLimit-Resource"); v != "" {
+		resource = v
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rateLimit := state.limits[resource]
+	if rateLimit == nil {
+		rateLimit = &GitHubRateLimit{Token: state.index, Resource: resource}
+		state.limits[resource] = rateLimit
+	}
+	rateLimit.Limit = limit
+	rateLimit.Remaining = remaining
+	rateLimit.Used = used
+	rateLimit.Reset = time.Unix(reset, 0)
+}
+
+// addGraphQLCost records the cost of GraphQL query reported by the rateLimit field.
+func (l *githubRateLimiter) addGraphQLCost(cost int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.graphqlCost += cost
+}
+
+func (l *githubRateLimiter) totalGraphQLCost() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.graphqlCost
+}
+
+func (l *githubRateLimiter) rateLimits() []*GitHubRateLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var ret []*GitHubRateLimit
+	for _, state := range l.tokens {
+		for _, limit := range state.limits {
+			v := *limit
+			ret = append(ret, &v)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Token != ret[j].Token {
+			return ret[i].Token < ret[j].Token
+		}
+		return ret[i].Resource < ret[j].Resource
+	})
+	return ret
+}
+
+// githubTransport sends the request with the token selected by githubRateLimiter,
+// and retries the request rejected by the primary or secondary rate limit.
+type githubTransport struct {
+	limiter  *githubRateLimiter
+	resource string
+	base     http.RoundTripper
+}
+
+func (t *githubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for retry := 0; ; retry++ {
+		state, err := t.limiter.acquire(ctx, t.resource)
+		if err != nil {
+			return nil, err
+		}
+		tk, err := state.token.issuer(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("modrank: failed to issue GitHub API access token: %w", err)
+		}
+		r := req.Clone(ctx)
+		if retry > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		r.Header.Set("Authorization", "Bearer "+tk)
+		resp, err := t.base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		t.limiter.update(state, t.resource, resp.Header)
+
+		wait, limited := githubRetryWait(resp)
+		if !limited || retry >= githubMaxRetry || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		logger(ctx).WarnContext(ctx, "GitHub API request is rate limited", "token", state.index, "resource", t.resource, "status", resp.StatusCode, "wait", wait)
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// githubRetryWait returns the waiting time to retry if the response is rejected by the rate limit.
+// For the primary rate limit, the waiting time is zero because githubRateLimiter waits until the reset
+// or another token in the pool is selected.
+func githubRetryWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		sec, err := strconv.Atoi(v)
+		if err == nil {
+			return time.Duration(sec) * time.Second, true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		return 0, true
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if strings.Contains(strings.ToLower(string(body)), "secondary rate limit") {
+		return githubSecondaryRateLimitWait, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
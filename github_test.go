@@ -0,0 +1,503 @@
+package modrank_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-modrank"
+	"github.com/goccy/go-modrank/repository"
+)
+
+func TestGitHubClient_EnterpriseServer(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/api/graphql" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if !strings.Contains(body.Query, `repository(owner: "org", name: "app")`) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"repo0": map[string]any{
+					"name":             "app",
+					"isArchived":       true,
+					"defaultBranchRef": map[string]any{"target": map[string]any{"oid": "head"}},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := modrank.NewGitHubClient(
+		ctx,
+		modrank.GitHubStaticAccessToken("token"),
+		modrank.GitHubBaseURL(server.URL),
+		modrank.GitHubHosts("ssh.github.example.com"),
+	)
+	// the port of the base URL is kept to distinguish the instances on the same host name.
+	if got, expected := client.RepositoryURL("org", "app"), "https://"+strings.TrimPrefix(server.URL, "http://")+"/org/app.git"; got != expected {
+		t.Fatalf("unexpected repository url: %s", got)
+	}
+	app, err := repository.New(client.RepositoryURL("org", "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshApp, err := repository.New("git@ssh.github.example.com:org/app.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := repository.New("https://github.com/org/app.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !client.Match(app) || !client.Match(sshApp) {
+		t.Fatal("failed to match GitHub Enterprise Server repositories")
+	}
+	if client.Match(public) {
+		t.Fatal("unexpected match to GitHub.com repository")
+	}
+	if err := client.CreateGitHubRepositoryCache(ctx, []*repository.Repository{app, public}); err != nil {
+		t.Fatal(err)
+	}
+	archived, err := client.IsArchived(ctx, "org", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !archived {
+		t.Fatal("failed to get archived state")
+	}
+	head, err := client.GetHeadCommit(ctx, "org", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != "head" {
+		t.Fatalf("unexpected head commit: %s", head)
+	}
+}
+
+func TestGitHubClient_FindRepositoriesByOwner(t *testing.T) {
+	ctx := context.Background()
+	node := func(name, lang string, topics ...string) map[string]any {
+		topicNodes := []map[string]any{}
+		for _, topic := range topics {
+			topicNodes = append(topicNodes, map[string]any{"topic": map[string]any{"name": topic}})
+		}
+		return map[string]any{
+			"name":             name,
+			"nameWithOwner":    "org/" + name,
+			"visibility":       "PUBLIC",
+			"stargazerCount":   3,
+			"pushedAt":         "2025-03-01T00:00:00Z",
+			"defaultBranchRef": map[string]any{"name": "main"},
+			"primaryLanguage":  map[string]any{"name": lang},
+			"repositoryTopics": map[string]any{"nodes": topicNodes},
+		}
+	}
+	var (
+		searchQueries []string
+		mu            sync.Mutex
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var data map[string]any
+		switch {
+		case strings.Contains(body.Query, "__typename"):
+			data = map[string]any{"repositoryOwner": map[string]any{"__typename": "Organization"}}
+		case strings.Contains(body.Query, "search("):
+			query, _ := body.Variables["query"].(string)
+			mu.Lock()
+			searchQueries = append(searchQueries, query)
+			mu.Unlock()
+			nodes := []map[string]any{node("app", "Go", "backend", "api")}
+			if strings.Contains(query, "topic:api") {
+				nodes = append(nodes, node("gateway", "Go", "api"))
+			}
+			data = map[string]any{
+				"search": map[string]any{
+					"nodes":    nodes,
+					"pageInfo": map[string]any{"hasNextPage": false},
+				},
+			}
+		case strings.Contains(body.Query, "repositories("):
+			if body.Variables["owner"] != "org" || body.Variables["isFork"] != false {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			nodes := []map[string]any{node("app", "Go", "backend"), node("web", "TypeScript")}
+			if body.Variables["withTopics"] == false {
+				// the same as the GraphQL server skipping the field by @include directive.
+				for _, n := range nodes {
+					delete(n, "repositoryTopics")
+				}
+			}
+			data = map[string]any{
+				"repositoryOwner": map[string]any{
+					"repositories": map[string]any{
+						"nodes":    nodes,
+						"pageInfo": map[string]any{"hasNextPage": false},
+					},
+				},
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer server.Close()
+
+	client := modrank.NewGitHubClient(ctx, modrank.GitHubStaticAccessToken("token"), modrank.GitHubBaseURL(server.URL))
+	t.Run("search", func(t *testing.T) {
+		pushedSince, err := modrank.ParsePushedSince("2025-01-01")
+		if err != nil {
+			t.Fatal(err)
+		}
+		repos, err := client.FindRepositoriesByOwner(ctx, "org", &modrank.GitHubRepositoryFilter{
+			Topics:       []string{"backend", "api"},
+			Languages:    []string{"go"},
+			ExcludeForks: true,
+			Visibility:   "public",
+			PushedSince:  pushedSince,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedQueries := []string{
+			"org:org archived:false fork:false is:public pushed:>=2025-01-01T00:00:00Z topic:backend language:go",
+			"org:org archived:false fork:false is:public pushed:>=2025-01-01T00:00:00Z topic:api language:go",
+		}
+		if !slices.Equal(searchQueries, expectedQueries) {
+			t.Fatalf("unexpected search queries: %q", searchQueries)
+		}
+		if len(repos) != 2 {
+			t.Fatalf("unexpected repository num: %d", len(repos))
+		}
+		repo := repos[0]
+		if repo.NameWithOwner != "org/app" || repo.DefaultBranch != "main" || repo.Stars != 3 || repo.Visibility != "public" || len(repo.Topics) != 2 {
+			t.Fatalf("unexpected repository: %+v", repo)
+		}
+		if repos[1].NameWithOwner != "org/gateway" {
+			t.Fatalf("unexpected repository: %+v", repos[1])
+		}
+	})
+	t.Run("list", func(t *testing.T) {
+		repos, err := client.FindRepositoriesByOwner(ctx, "org", &modrank.GitHubRepositoryFilter{ExcludeForks: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(repos) != 2 || repos[0].NameWithOwner != "org/app" || repos[1].NameWithOwner != "org/web" {
+			t.Fatalf("unexpected repositories: %+v", repos)
+		}
+		// the topics are fetched even if the filter doesn't specify them.
+		if !slices.Equal(repos[0].Topics, []string{"backend"}) {
+			t.Fatalf("unexpected topics: %q", repos[0].Topics)
+		}
+	})
+}
+
+func TestGitHubClient_RateLimit(t *testing.T) {
+	ctx := context.Background()
+	var secondaryLimited bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		reset := fmt.Sprint(time.Now().Add(time.Hour).Unix())
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", reset)
+		w.Header().Set("X-RateLimit-Resource", "graphql")
+		switch req.Header.Get("Authorization") {
+		case "Bearer exhausted":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		case "Bearer available":
+			w.Header().Set("X-RateLimit-Remaining", "4000")
+			if !secondaryLimited {
+				secondaryLimited = true
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"message": "You have exceeded a secondary rate limit."}`))
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"rateLimit": map[string]any{"cost": 1},
+				"repo0": map[string]any{
+					"name":             "app",
+					"defaultBranchRef": map[string]any{"target": map[string]any{"oid": "head"}},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := modrank.NewGitHubClient(
+		ctx,
+		modrank.GitHubStaticAccessToken("unused"),
+		modrank.GitHubBaseURL(server.URL),
+		modrank.GitHubTokenPool(
+			modrank.GitHubStaticAccessToken("exhausted"),
+			modrank.GitHubStaticAccessToken("available"),
+		),
+	)
+	app, err := repository.New(client.RepositoryURL("org", "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateGitHubRepositoryCache(ctx, []*repository.Repository{app}); err != nil {
+		t.Fatal(err)
+	}
+	if !secondaryLimited {
+		t.Fatal("failed to retry the request rejected by the secondary rate limit")
+	}
+	head, err := client.GetHeadCommit(ctx, "org", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != "head" {
+		t.Fatalf("unexpected head commit: %s", head)
+	}
+	limits := client.RateLimits()
+	if len(limits) != 2 {
+		t.Fatalf("unexpected rate limit num: %d", len(limits))
+	}
+	if limits[0].Token != 0 || limits[0].Remaining != 0 || limits[1].Token != 1 || limits[1].Remaining != 4000 {
+		t.Fatalf("unexpected rate limits: %+v %+v", limits[0], limits[1])
+	}
+	if cost := client.GraphQLCost(); cost != 1 {
+		t.Fatalf("unexpected GraphQL cost: %d", cost)
+	}
+}
+
+func TestModRank_UpdateRepositoryStatusWithPartialErrors(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/graphql":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{
+					"rateLimit": map[string]any{"cost": 1},
+					"repo0":     nil,
+					"repo1": map[string]any{
+						"nameWithOwner":    "new-org/renamed",
+						"defaultBranchRef": map[string]any{"target": map[string]any{"oid": "head"}},
+					},
+				},
+				"errors": []map[string]any{
+					{"type": "NOT_FOUND", "path": []string{"repo0"}, "message": "Could not resolve to a Repository with the name 'org/deleted'."},
+				},
+			})
+		case "/api/v3/repos/new-org/renamed/git/trees/head":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"sha":  "head",
+				"tree": []map[string]any{{"path": "go.mod", "type": "blob"}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	storage, err := modrank.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := modrank.New(
+		ctx,
+		modrank.WithStorage(storage),
+		modrank.WithGitHubToken(func(_ context.Context) (string, error) { return "token", nil }),
+		modrank.WithGitHubBaseURL(server.URL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(server.URL, "http://")
+	deleted, err := repository.New(fmt.Sprintf("https://%s/org/deleted.git", host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamed, err := repository.New(fmt.Sprintf("https://%s/org/old-name.git", host))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the repository had go.mod before it was deleted.
+	if err := storage.InsertOrUpdateRepository(ctx, &modrank.RepositoryStatus{
+		NameWithOwner:  deleted.FullName(),
+		HeadCommitHash: "last",
+		ExistsGoMod:    true,
+		NotFound:       true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UpdateRepositoryStatusByGitHubAPI(ctx, deleted, renamed); err != nil {
+		t.Fatal(err)
+	}
+	deletedStat, err := storage.FindRepositoryByName(ctx, deleted.FullName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deletedStat.NotFound {
+		t.Fatal("failed to save not found status")
+	}
+	if deletedStat.HeadCommitHash != "last" || !deletedStat.ExistsGoMod {
+		t.Fatalf("failed to keep the last status of not found repository: %+v", deletedStat)
+	}
+	renamedStat, err := storage.FindRepositoryByName(ctx, renamed.FullName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamedStat.NotFound || !renamedStat.ExistsGoMod {
+		t.Fatalf("unexpected status of renamed repository: %+v", renamedStat)
+	}
+	if expected := host + "/new-org/renamed"; renamedStat.CanonicalName != expected {
+		t.Fatalf("unexpected canonical name: expected %s but got %s", expected, renamedStat.CanonicalName)
+	}
+}
+
+type rewriteTransport struct {
+	target *url.URL
+	hosts  []string
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.hosts = append(t.hosts, req.URL.Host)
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestModRank_WithHTTPClient(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/graphql":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{
+					"repo0": map[string]any{
+						"nameWithOwner":    "org/app",
+						"defaultBranchRef": map[string]any{"target": map[string]any{"oid": "head"}},
+					},
+				},
+			})
+		case "/repos/org/app/git/trees/head":
+			_ = json.NewEncoder(w).Encode(map[string]any{"sha": "head", "tree": []map[string]any{}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	target, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &rewriteTransport{target: target}
+	storage, err := modrank.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := modrank.New(ctx, modrank.WithStorage(storage), modrank.WithHTTPClient(&http.Client{Transport: transport}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := repository.New("https://github.com/org/app.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UpdateRepositoryStatusByGitHubAPI(ctx, repo); err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.hosts) != 2 {
+		t.Fatalf("unexpected request num: %v", transport.hosts)
+	}
+	stat, err := storage.FindRepositoryByName(ctx, repo.FullName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.ExistsGoMod {
+		t.Fatal("unexpected go.mod status")
+	}
+}
+
+func TestModRank_GitHubClient(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4000")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+		w.Header().Set("X-RateLimit-Resource", "graphql")
+		var body struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data := map[string]any{"repositoryOwner": map[string]any{"__typename": "Organization"}}
+		if strings.Contains(body.Query, "repositories(") {
+			data = map[string]any{
+				"repositoryOwner": map[string]any{
+					"repositories": map[string]any{
+						"nodes":    []map[string]any{{"name": "app", "nameWithOwner": "org/app"}},
+						"pageInfo": map[string]any{"hasNextPage": false},
+					},
+				},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	defer server.Close()
+
+	r, err := modrank.New(ctx, modrank.WithGitHubBaseURL(server.URL), modrank.WithGitHubToken(func(_ context.Context) (string, error) {
+		return "token", nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, err := modrank.FindRepositories(ctx, modrank.NewGitHubOwnerSource(r.GitHubClient(), "org", &modrank.GitHubRepositoryFilter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 {
+		t.Fatalf("unexpected repository num: %d", len(repos))
+	}
+	// the discovery shares the rate limit state with ModRank.
+	limits := r.GitHubRateLimits()
+	if len(limits) != 1 || limits[0].Remaining != 4000 {
+		t.Fatalf("unexpected rate limits: %+v", limits)
+	}
+}
@@ -0,0 +1,185 @@
+package modrank
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+
+	// githubAppJWTLifetime is the lifetime of JWT to authenticate as GitHub App. GitHub allows up to 10 minutes.
+	githubAppJWTLifetime = 9 * time.Minute
+	// githubAppTokenRefreshMargin is the margin to refresh the installation token before it expires.
+	githubAppTokenRefreshMargin = 5 * time.Minute
+)
+
+// GitHubAppTokenIssuer issues the installation access token of GitHub App.
+// The token is cached until shortly before it expires and refreshed automatically,
+// so Issue can be used as TokenIssuer for long scans that exceed the one-hour lifetime of the token.
+//
+//	issuer, err := modrank.NewGitHubAppTokenIssuerFromFile(appID, installationID, "app.private-key.pem")
+//	modrank.WithGitHubToken(issuer.Issue)
+//	modrank.WithGitAccessToken(issuer.Issue)
+//	repository.WithAuthToken(issuer.Issue)
+type GitHubAppTokenIssuer struct {
+	httpClientHolder
+
+	appID          int64
+	installationID int64
+	privateKey     *rsa.PrivateKey
+	apiURL         string
+	token          string
+	expiresAt      time.Time
+	mu             sync.Mutex
+}
+
+type GitHubAppTokenIssuerOption func(*GitHubAppTokenIssuer)
+
+// GitHubAppBaseURL specify the base URL of GitHub Enterprise Server on which the GitHub App is installed.
+// e.g.) https://github.example.com
+func GitHubAppBaseURL(baseURL string) GitHubAppTokenIssuerOption {
+	return func(i *GitHubAppTokenIssuer) {
+		i.apiURL = strings.TrimSuffix(baseURL, "/") + "/api/v3"
+	}
+}
+
+// NewGitHubAppTokenIssuer creates the issuer from the PEM encoded private key of GitHub App.
+func NewGitHubAppTokenIssuer(appID, installationID int64, privateKey []byte, opts ...GitHubAppTokenIssuerOption) (*GitHubAppTokenIssuer, error) {
+	key, err := parseGitHubAppPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	issuer := &GitHubAppTokenIssuer{
+		appID:          appID,
+		installationID: installationID,
+		privateKey:     key,
+		apiURL:         defaultGitHubAPIURL,
+	}
+	for _, opt := range opts {
+		opt(issuer)
+	}
+	return issuer, nil
+}
+
+// NewGitHubAppTokenIssuerFromFile creates the issuer from the private key file of GitHub App.
+func NewGitHubAppTokenIssuerFromFile(appID, installationID int64, keyPath string, opts ...GitHubAppTokenIssuerOption) (*GitHubAppTokenIssuer, error) {
+	privateKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+	}
+	return NewGitHubAppTokenIssuer(appID, installationID, privateKey, opts...)
+}
+
+// Issue returns the cached installation access token, or creates a new one if it expires soon.
+func (i *GitHubAppTokenIssuer) Issue(ctx context.Context) (string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.token != "" && time.Now().Add(githubAppTokenRefreshMargin).Before(i.expiresAt) {
+		return i.token, nil
+	}
+	token, expiresAt, err := i.createInstallationToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GitHub App installation token: %w", err)
+	}
+	i.token = token
+	i.expiresAt = expiresAt
+	return token, nil
+}
+
+func (i *GitHubAppTokenIssuer) createInstallationToken(ctx context.Context) (string, time.Time, error) {
+	jwt, err := i.signJWT(time.Now())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	reqURL := fmt.Sprintf("%s/app/installations/%d/access_tokens", i.apiURL, i.installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := i.client().Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return "", time.Time{}, fmt.Errorf("failed to call %s: %s: %s", reqURL, resp.Status, string(body))
+	}
+	var v struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return "", time.Time{}, err
+	}
+	if v.Token == "" {
+		return "", time.Time{}, errors.New("installation token is empty")
+	}
+	return v.Token, v.ExpiresAt, nil
+}
+
+// signJWT creates JWT signed by RS256 to authenticate as GitHub App.
+// The issued time is set 60 seconds in the past to allow for clock drift.
+func (i *GitHubAppTokenIssuer) signJWT(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(githubAppJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(i.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, i.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+func parseGitHubAppPrivateKey(privateKey []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("failed to decode GitHub App private key: PEM block is not found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key must be RSA private key")
+	}
+	return rsaKey, nil
+}
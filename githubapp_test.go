@@ -0,0 +1,112 @@
+package modrank_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-modrank"
+)
+
+func TestGitHubAppTokenIssuer(t *testing.T) {
+	ctx := context.Background()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var (
+		called    int
+		expiresIn time.Duration
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.URL.Path != "/api/v3/app/installations/2/access_tokens" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		parts := strings.Split(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "), ".")
+		if len(parts) != 3 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var v struct {
+			Iss string `json:"iss"`
+		}
+		if err := json.Unmarshal(claims, &v); err != nil || v.Iss != "1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		called++
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"token":      fmt.Sprintf("token%d", called),
+			"expires_at": time.Now().Add(expiresIn).Format(time.RFC3339),
+		})
+	}))
+	defer server.Close()
+
+	issuer, err := modrank.NewGitHubAppTokenIssuer(1, 2, privateKey, modrank.GitHubAppBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		expiresIn = time.Hour
+		for range 2 {
+			tk, err := issuer.Issue(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tk != "token1" {
+				t.Fatalf("unexpected token: %s", tk)
+			}
+		}
+	})
+	t.Run("refresh", func(t *testing.T) {
+		issuer, err := modrank.NewGitHubAppTokenIssuer(1, 2, privateKey, modrank.GitHubAppBaseURL(server.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the token expiring within the refresh margin is refreshed on the next call.
+		expiresIn = time.Minute
+		first, err := issuer.Issue(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := issuer.Issue(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == second {
+			t.Fatalf("failed to refresh token: %s", first)
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package modrank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/goccy/go-modrank/repository"
+)
+
+const (
+	// DefaultGitLabURL is the base URL of GitLab.com.
+	DefaultGitLabURL = "https://gitlab.com"
+	// gitlabAuthUsername is the username to clone projects by the access token.
+	gitlabAuthUsername = "oauth2"
+)
+
+type GitLabAccessToken = GitAccessToken
+
+func GitLabStaticAccessToken(tk string) *GitLabAccessToken {
+	return &GitAccessToken{
+		issuer: func(_ context.Context) (string, error) {
+			return tk, nil
+		},
+	}
+}
+
+var _ RepositoryHost = new(GitLabClient)
+
+// GitLabClient is the client of GitLab REST API. It implements RepositoryHost for repositories hosted by the GitLab instance.
+type GitLabClient struct {
+	httpClientHolder
+
+	baseURL           *url.URL
+	gitlabAccessToken *GitLabAccessToken
+	repoCache         map[string]*GitLabProject
+	repoCacheMu       sync.RWMutex
+}
+
+// GitLabProject represents the GitLab project.
+type GitLabProject struct {
+	ID                int64  `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	Archived          bool   `json:"archived"`
+	DefaultBranch     string `json:"default_branch"`
+	EmptyRepo         bool   `json:"empty_repo"`
+	// HeadCommit is the head commit hash of the default branch. It's set by CreateRepositoryCache.
+	HeadCommit string `json:"-"`
+	// Err is the error of the project returned by the API such as not found or forbidden. It's set by CreateRepositoryCache.
+	Err error `json:"-"`
+}
+
+// NewGitLabClient creates the client for the GitLab instance. e.g.) https://gitlab.com
+// Repositories whose host is the same as the baseURL are handled by this client.
+func NewGitLabClient(ctx context.Context, baseURL string, token *GitLabAccessToken) (*GitLabClient, error) {
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("modrank: invalid GitLab URL %s: %w", baseURL, err)
+	}
+	if parsedURL.Host == "" {
+		return nil, fmt.Errorf("modrank: invalid GitLab URL %s", baseURL)
+	}
+	return &GitLabClient{
+		baseURL:           parsedURL,
+		gitlabAccessToken: token,
+		repoCache:         make(map[string]*GitLabProject),
+	}, nil
+}
+
+// Match reports whether the repository is hosted by this GitLab instance.
+func (c *GitLabClient) Match(repo *repository.Repository) bool {
+	return repo.Host() == repository.URLHost(c.baseURL)
+}
+
+// CloneAuth returns the GitLab access token of the client to clone projects as oauth2 user.
+func (c *GitLabClient) CloneAuth() (repository.TokenIssuer, string) {
+	if c.gitlabAccessToken == nil {
+		return nil, gitlabAuthUsername
+	}
+	return repository.TokenIssuer(c.gitlabAccessToken.issuer), gitlabAuthUsername
+}
+
+// FindProjectsByGroup returns all projects in the group including its subgroups recursively.
+// Archived projects are also returned, so check GitLabProject.Archived if you want to skip them.
+func (c *GitLabClient) FindProjectsByGroup(ctx context.Context, group string) ([]*GitLabProject, error) {
+	var projects []*GitLabProject
+	query := url.Values{}
+	query.Set("include_subgroups", "true")
+	query.Set("with_shared", "false")
+	query.Set("per_page", "100")
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		var pageProjects []*GitLabProject
+		nextPage, err := c.get(ctx, path.Join("groups", url.PathEscape(group), "projects"), query, &pageProjects)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get projects of %s group: %w", group, err)
+		}
+		projects = append(projects, pageProjects...)
+		page = nextPage
+	}
+	return projects, nil
+}
+
+func (c *GitLabClient) IsArchived(ctx context.Context, repo *repository.Repository) (bool, error) {
+	project := c.getRepositoryFromCache(repo.NameWithOwner())
+	if project == nil {
+		return false, errors.New("cannot use IsArchived unless you create a cache in advance by CreateRepositoryCache")
+	}
+	if project.Err != nil {
+		return false, project.Err
+	}
+	return project.Archived, nil
+}
+
+func (c *GitLabClient) GetHeadCommit(ctx context.Context, repo *repository.Repository) (string, error) {
+	project := c.getRepositoryFromCache(repo.NameWithOwner())
+	if project == nil {
+		return "", errors.New("cannot use GetHeadCommit unless you create a cache in advance by CreateRepositoryCache")
+	}
+	if project.Err != nil {
+		return "", project.Err
+	}
+	return project.HeadCommit, nil
+}
+
+// ExistsGoMod returns whether go.mod exists in the tree of the head commit of the default branch.
+func (c *GitLabClient) ExistsGoMod(ctx context.Context, repo *repository.Repository) (bool, error) {
+	head, err := c.GetHeadCommit(ctx, repo)
+	if err != nil {
+		return false, err
+	}
+	if head == "" {
+		return false, nil
+	}
+	project := c.getRepositoryFromCache(repo.NameWithOwner())
+	query := url.Values{}
+	query.Set("ref", head)
+	query.Set("recursive", "true")
+	query.Set("per_page", "100")
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		var entries []struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		}
+		nextPage, err := c.get(ctx, path.Join("projects", strconv.FormatInt(project.ID, 10), "repository", "tree"), query, &entries)
+		if err != nil {
+			if errors.Is(err, errAPINotFound) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to get tree from head commit of default branch: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.Type == "blob" && entry.Name == "go.mod" {
+				return true, nil
+			}
+		}
+		page = nextPage
+	}
+	return false, nil
+}
+
+// CreateRepositoryCache prefetches the archived status and the head commit of the default branch of the repositories.
+func (c *GitLabClient) CreateRepositoryCache(ctx context.Context, repos []*repository.Repository) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(hostAPIConcurrency)
+	for _, repo := range repos {
+		if !c.Match(repo) {
+			continue
+		}
+		eg.Go(func() error {
+			project, err := c.getProject(ctx, repo.NameWithOwner())
+			if err != nil {
+				repoErr := newRepositoryError(repo.NameWithOwner(), err)
+				if repoErr == nil {
+					return err
+				}
+				// the project is deleted or the token has no permission to access it.
+				project = &GitLabProject{PathWithNamespace: repo.NameWithOwner(), Err: repoErr}
+			}
+			c.setRepositoryCache(repo.NameWithOwner(), project)
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+func (c *GitLabClient) getProject(ctx context.Context, pathWithNamespace string) (*GitLabProject, error) {
+	var project GitLabProject
+	if _, err := c.get(ctx, path.Join("projects", url.PathEscape(pathWithNamespace)), nil, &project); err != nil {
+		return nil, fmt.Errorf("failed to get %s project: %w", pathWithNamespace, err)
+	}
+	if project.EmptyRepo || project.DefaultBranch == "" {
+		return &project, nil
+	}
+	var branch struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	if _, err := c.get(
+		ctx,
+		path.Join("projects", strconv.FormatInt(project.ID, 10), "repository", "branches", url.PathEscape(project.DefaultBranch)),
+		nil,
+		&branch,
+	); err != nil {
+		return nil, fmt.Errorf("failed to get default branch of %s project: %w", pathWithNamespace, err)
+	}
+	project.HeadCommit = branch.Commit.ID
+	return &project, nil
+}
+
+// get calls GET method of GitLab REST API and decodes the response to v.
+// It returns the next page number by X-Next-Page header. If the response is the last page, returns empty string.
+func (c *GitLabClient) get(ctx context.Context, apiPath string, query url.Values, v any) (string, error) {
+	reqURL := c.baseURL.JoinPath("api", "v4")
+	// apiPath contains escaped path segments such as group%2Fproject, so set it as the raw path.
+	reqURL.RawPath = reqURL.EscapedPath() + "/" + apiPath
+	reqURL.Path, _ = url.PathUnescape(reqURL.RawPath)
+	reqURL.RawQuery = query.Encode()
+
+	var authorization string
+	if c.gitlabAccessToken != nil {
+		tk, err := c.gitlabAccessToken.issuer(ctx)
+		if err != nil {
+			return "", fmt.Errorf("modrank: failed to issue GitLab API access token: %w", err)
+		}
+		if tk != "" {
+			authorization = "Bearer " + tk
+		}
+	}
+	header, err := getJSON(ctx, c.client(), reqURL.String(), authorization, v)
+	if err != nil {
+		return "", err
+	}
+	return header.Get("X-Next-Page"), nil
+}
+
+func (c *GitLabClient) getRepositoryFromCache(pathWithNamespace string) *GitLabProject {
+	c.repoCacheMu.RLock()
+	project := c.repoCache[pathWithNamespace]
+	c.repoCacheMu.RUnlock()
+	return project
+}
+
+func (c *GitLabClient) setRepositoryCache(pathWithNamespace string, project *GitLabProject) {
+	c.repoCacheMu.Lock()
+	c.repoCache[pathWithNamespace] = project
+	c.repoCacheMu.Unlock()
+}
@@ -0,0 +1,193 @@
+package modrank_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-modrank"
+	"github.com/goccy/go-modrank/repository"
+)
+
+func newFakeGitLabServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch req.URL.EscapedPath() {
+		case "/api/v4/groups/group/projects":
+			if req.URL.Query().Get("include_subgroups") != "true" {
+				t.Errorf("subgroups must be included")
+			}
+			if req.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				writeJSON(w, []map[string]any{
+					{"id": 1, "path_with_namespace": "group/app", "http_url_to_repo": server.URL + "/group/app.git", "default_branch": "main"},
+				})
+				return
+			}
+			writeJSON(w, []map[string]any{
+				{"id": 2, "path_with_namespace": "group/sub/lib", "http_url_to_repo": server.URL + "/group/sub/lib.git", "default_branch": "main"},
+				{"id": 3, "path_with_namespace": "group/old", "http_url_to_repo": server.URL + "/group/old.git", "archived": true},
+			})
+		case "/api/v4/projects/group%2Fapp":
+			writeJSON(w, map[string]any{"id": 1, "path_with_namespace": "group/app", "default_branch": "main"})
+		case "/api/v4/projects/group%2Fsub%2Flib":
+			writeJSON(w, map[string]any{"id": 2, "path_with_namespace": "group/sub/lib", "default_branch": "main"})
+		case "/api/v4/projects/group%2Fold":
+			writeJSON(w, map[string]any{"id": 3, "path_with_namespace": "group/old", "archived": true, "empty_repo": true})
+		case "/api/v4/projects/group%2Fprivate":
+			w.WriteHeader(http.StatusForbidden)
+		case "/api/v4/projects/group%2Fbroken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/api/v4/projects/1/repository/branches/main":
+			writeJSON(w, map[string]any{"commit": map[string]any{"id": "head1"}})
+		case "/api/v4/projects/2/repository/branches/main":
+			writeJSON(w, map[string]any{"commit": map[string]any{"id": "head2"}})
+		case "/api/v4/projects/1/repository/tree":
+			if req.URL.Query().Get("ref") != "head1" {
+				t.Errorf("unexpected ref: %s", req.URL.Query().Get("ref"))
+			}
+			if req.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				writeJSON(w, []map[string]any{{"type": "tree", "name": "cmd"}})
+				return
+			}
+			writeJSON(w, []map[string]any{{"type": "blob", "name": "go.mod"}})
+		case "/api/v4/projects/2/repository/tree":
+			writeJSON(w, []map[string]any{{"type": "blob", "name": "README.md"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGitLabClient(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeGitLabServer(t)
+	client, err := modrank.NewGitLabClient(ctx, server.URL, modrank.GitLabStaticAccessToken("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcRepos, err := modrank.FindRepositories(ctx, modrank.NewGitLabGroupSource(client, "group"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := sourceRepositories(srcRepos)
+	if len(repos) != 2 {
+		t.Fatalf("unexpected repository number: %d", len(repos))
+	}
+	if err := client.CreateRepositoryCache(ctx, repos); err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		name        string
+		head        string
+		existsGoMod bool
+	}{
+		{name: "group/app", head: "head1", existsGoMod: true},
+		{name: "group/sub/lib", head: "head2", existsGoMod: false},
+	}
+	for idx, repo := range repos {
+		if !client.Match(repo) {
+			t.Fatalf("%s must be hosted by GitLab", repo.FullName())
+		}
+		if repo.NameWithOwner() != expected[idx].name {
+			t.Fatalf("unexpected repository: expected %s but got %s", expected[idx].name, repo.NameWithOwner())
+		}
+		isArchived, err := client.IsArchived(ctx, repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isArchived {
+			t.Fatalf("%s is not archived", repo.NameWithOwner())
+		}
+		head, err := client.GetHeadCommit(ctx, repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head != expected[idx].head {
+			t.Fatalf("unexpected head commit: expected %s but got %s", expected[idx].head, head)
+		}
+		existsGoMod, err := client.ExistsGoMod(ctx, repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if existsGoMod != expected[idx].existsGoMod {
+			t.Fatalf("unexpected go.mod status of %s", repo.NameWithOwner())
+		}
+	}
+	githubRepo, err := repository.New("https://github.com/goccy/go-modrank.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Match(githubRepo) {
+		t.Fatal("GitHub repository must not be hosted by GitLab")
+	}
+	// the instance on the same host name but the other port is distinguished.
+	otherPortRepo, err := repository.New("http://127.0.0.1:1/group/app.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Match(otherPortRepo) {
+		t.Fatal("the repository on the other port must not be hosted by GitLab")
+	}
+}
+
+func TestGitLabClientRepositoryError(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeGitLabServer(t)
+	client, err := modrank.NewGitLabClient(ctx, server.URL, modrank.GitLabStaticAccessToken("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRepo := func(name string) *repository.Repository {
+		repo, err := repository.New(server.URL + "/" + name + ".git")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	}
+	app, deleted, private := newRepo("group/app"), newRepo("group/deleted"), newRepo("group/private")
+
+	// the project that can't be accessed doesn't stop prefetching other projects.
+	if err := client.CreateRepositoryCache(ctx, []*repository.Repository{deleted, app, private}); err != nil {
+		t.Fatal(err)
+	}
+	if head, err := client.GetHeadCommit(ctx, app); err != nil || head != "head1" {
+		t.Fatalf("unexpected head commit: %s, %v", head, err)
+	}
+	if _, err := client.GetHeadCommit(ctx, deleted); !errors.Is(err, modrank.ErrRepositoryNotFound) {
+		t.Fatalf("expected not found error but got %v", err)
+	}
+	_, err = client.IsArchived(ctx, private)
+	var repoErr *modrank.RepositoryError
+	if !errors.As(err, &repoErr) || errors.Is(err, modrank.ErrRepositoryNotFound) {
+		t.Fatalf("expected repository error but got %v", err)
+	}
+	if repoErr.Type != "FORBIDDEN" {
+		t.Fatalf("unexpected error type: %s", repoErr.Type)
+	}
+
+	// the server error is not the error of the project.
+	if err := client.CreateRepositoryCache(ctx, []*repository.Repository{newRepo("group/broken")}); err == nil {
+		t.Fatal("expected error for server error")
+	}
+}
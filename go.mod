@@ -5,10 +5,12 @@ go 1.24.1
 require (
 	github.com/glebarez/go-sqlite v1.22.0
 	github.com/go-git/go-git/v5 v5.14.0
+	github.com/go-sql-driver/mysql v1.9.3
 	github.com/goccy/go-yaml v1.17.1
 	github.com/gocolly/colly v1.2.0
 	github.com/google/go-github/v70 v70.0.0
 	github.com/jessevdk/go-flags v1.6.1
+	github.com/lib/pq v1.10.9
 	github.com/shurcooL/githubv4 v0.0.0-20240727222349-48295856cce7
 	golang.org/x/mod v0.17.0
 	golang.org/x/oauth2 v0.28.0
@@ -17,6 +19,7 @@ require (
 
 require (
 	dario.cat/mergo v1.0.0 // indirect
+	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/Microsoft/go-winio v0.6.2 // indirect
 	github.com/ProtonMail/go-crypto v1.1.5 // indirect
 	github.com/PuerkitoBio/goquery v1.10.2 // indirect
@@ -0,0 +1,259 @@
+package modrank
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// HostedRepositoryRoot is the root of the repository hosting the Go module.
+type HostedRepositoryRoot struct {
+	// Prefix is the import path corresponding to the root of the repository. e.g.) k8s.io/client-go
+	Prefix string
+	// Repository is the hosted repository name. e.g.) github.com/kubernetes/client-go
+	Repository string
+	// VCS is the version control system of the repository. e.g.) git
+	VCS string
+	// Subdirectory is the directory containing the module within the repository. e.g.) go
+	Subdirectory string
+}
+
+// goImport is the content of the go-import meta tag.
+//
+//	<meta name="go-import" content="import-prefix vcs repo-root [subdirectory]">
+type goImport struct {
+	prefix   string
+	vcs      string
+	repoRoot string
+	subdir   string
+}
+
+const goImportModVCS = "mod"
+
+// knownHosts is the hosts whose repository root is the first three elements of the import path
+// without accessing the network, like the go command.
+var knownHosts = []string{
+	"github.com",
+	"bitbucket.org",
+}
+
+var (
+	gopkgInPat          = regexp.MustCompile(`^gopkg\.in/([^/.]+)\.v[0-9]+(?:/(.+))?$`)
+	gopkgInWithOwnerPat = regexp.MustCompile(`^gopkg\.in/([^/.]+)/([^/.]+)\.v[0-9]+(?:/(.+))?$`)
+	majorVersionPat     = regexp.MustCompile(`(^|/)v[0-9]+$`)
+)
+
+// getHostedRepositoryByKnownHost returns the root of the module on the known hosts such as github.com.
+func getHostedRepositoryByKnownHost(name string) (*HostedRepositoryRoot, error) {
+	parts := strings.Split(name, "/")
+	for _, host := range knownHosts {
+		if parts[0] != host {
+			continue
+		}
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("invalid module path for %s: %s", host, name)
+		}
+		prefix := strings.Join(parts[:3], "/")
+		return &HostedRepositoryRoot{
+			Prefix:       prefix,
+			Repository:   prefix,
+			VCS:          "git",
+			Subdirectory: moduleSubdirectory("", strings.Join(parts[3:], "/")),
+		}, nil
+	}
+	return nil, nil
+}
+
+// getHostedRepositoryByGoPkgIn returns the root of the module on gopkg.in.
+// e.g.) gopkg.in/yaml.v3 => github.com/go-yaml/yaml, gopkg.in/src-d/go-git.v4 => github.com/src-d/go-git
+func getHostedRepositoryByGoPkgIn(name string) (*HostedRepositoryRoot, error) {
+	if matched := gopkgInWithOwnerPat.FindStringSubmatch(name); matched != nil {
+		return &HostedRepositoryRoot{
+			Prefix:       strings.TrimSuffix(strings.TrimSuffix(name, matched[3]), "/"),
+			Repository:   fmt.Sprintf("github.com/%s/%s", matched[1], matched[2]),
+			VCS:          "git",
+			Subdirectory: moduleSubdirectory("", matched[3]),
+		}, nil
+	}
+	if matched := gopkgInPat.FindStringSubmatch(name); matched != nil {
+		return &HostedRepositoryRoot{
+			Prefix:       strings.TrimSuffix(strings.TrimSuffix(name, matched[2]), "/"),
+			Repository:   fmt.Sprintf("github.com/go-%[1]s/%[1]s", matched[1]),
+			VCS:          "git",
+			Subdirectory: moduleSubdirectory("", matched[2]),
+		}, nil
+	}
+	return nil, nil
+}
+
+// getHostedRepositoryByGoImportMetaTag resolves the root of the module by the go-import meta tags
+// with the same protocol as the go command:
+//
+//   - the meta tag whose import prefix is the module path or a parent of it is selected.
+//   - if both mod and vcs entries exist for the same prefix, the vcs entry is used because it points to the repository.
+//     If only the mod entry exists, the repository is resolved by the Origin data of the module proxy it points to.
+//   - if the prefix differs from the module path, the meta tags of the prefix are fetched again to verify them.
+func getHostedRepositoryByGoImportMetaTag(ctx context.Context, hc *http.Client, proxy *goProxy, name string) (*HostedRepositoryRoot, error) {
+	imports, err := fetchGoImports(ctx, hc, name)
+	if err != nil {
+		return nil, err
+	}
+	imp, err := matchGoImport(imports, name)
+	if err != nil {
+		return nil, err
+	}
+	if imp.prefix != name {
+		rootImports, err := fetchGoImports(ctx, hc, imp.prefix)
+		if err != nil {
+			return nil, err
+		}
+		rootImp, err := matchGoImport(rootImports, imp.prefix)
+		if err != nil {
+			return nil, err
+		}
+		if *rootImp != *imp {
+			return nil, fmt.Errorf("go-import meta tags of %s and %s are inconsistent", name, imp.prefix)
+		}
+	}
+	if imp.vcs == goImportModVCS {
+		root, err := proxy.resolveByProxy(ctx, hc, strings.TrimSuffix(imp.repoRoot, "/"), name)
+		if err != nil {
+			return nil, err
+		}
+		root.Prefix = imp.prefix
+		return root, nil
+	}
+	return &HostedRepositoryRoot{
+		Prefix:       imp.prefix,
+		Repository:   trimRepositoryURL(imp.repoRoot),
+		VCS:          imp.vcs,
+		Subdirectory: moduleSubdirectory(imp.subdir, strings.TrimPrefix(strings.TrimPrefix(name, imp.prefix), "/")),
+	}, nil
+}
+
+func fetchGoImports(ctx context.Context, hc *http.Client, name string) ([]*goImport, error) {
+	reqURL := fmt.Sprintf("https://%s?go-get=1", name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// the go command accepts the meta tags of error pages, so the status code is not checked.
+	return parseGoImports(resp.Body)
+}
+
+// parseGoImports parses the go-import meta tags in the head of the HTML.
+// Like the go command, the HTML is decoded by the non-strict XML decoder and the parsing stops at the body.
+func parseGoImports(r io.Reader) ([]*goImport, error) {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "utf-8", "ascii":
+			return input, nil
+		}
+		return nil, fmt.Errorf("can't decode XML document using charset %q", charset)
+	}
+	d.Strict = false
+	var imports []*goImport
+	for {
+		t, err := d.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) || len(imports) > 0 {
+				return imports, nil
+			}
+			return nil, err
+		}
+		if e, ok := t.(xml.StartElement); ok && strings.EqualFold(e.Name.Local, "body") {
+			return imports, nil
+		}
+		if e, ok := t.(xml.EndElement); ok && strings.EqualFold(e.Name.Local, "head") {
+			return imports, nil
+		}
+		e, ok := t.(xml.StartElement)
+		if !ok || !strings.EqualFold(e.Name.Local, "meta") {
+			continue
+		}
+		if xmlAttr(e, "name") != "go-import" {
+			continue
+		}
+		fields := strings.Fields(xmlAttr(e, "content"))
+		if len(fields) != 3 && len(fields) != 4 {
+			continue
+		}
+		imp := &goImport{
+			prefix:   fields[0],
+			vcs:      fields[1],
+			repoRoot: fields[2],
+		}
+		if len(fields) == 4 {
+			imp.subdir = strings.Trim(fields[3], "/")
+		}
+		imports = append(imports, imp)
+	}
+}
+
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, attr := range e.Attr {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// matchGoImport returns the go-import entry whose prefix matches the module path.
+// If there are two entries for the same prefix and one of them is mod, the other one is returned.
+func matchGoImport(imports []*goImport, name string) (*goImport, error) {
+	var matched []*goImport
+	for _, imp := range imports {
+		if name != imp.prefix && !strings.HasPrefix(name, imp.prefix+"/") {
+			continue
+		}
+		matched = append(matched, imp)
+	}
+	switch len(matched) {
+	case 0:
+		return nil, fmt.Errorf("go-import meta tag for %s is not found", name)
+	case 1:
+		return matched[0], nil
+	case 2:
+		if matched[0].prefix == matched[1].prefix {
+			if matched[0].vcs == goImportModVCS && matched[1].vcs != goImportModVCS {
+				return matched[1], nil
+			}
+			if matched[1].vcs == goImportModVCS && matched[0].vcs != goImportModVCS {
+				return matched[0], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("multiple go-import meta tags match %s: %s and %s", name, matched[0].prefix, matched[1].prefix)
+}
+
+// moduleSubdirectory returns the directory of the module within the repository from
+// the subdirectory of the repository root and the remaining path of the module after the prefix.
+// The major version suffix such as v2 is removed because it may be a branch instead of a directory.
+func moduleSubdirectory(subdir, rest string) string {
+	rest = majorVersionPat.ReplaceAllString(rest, "")
+	return strings.Trim(strings.Join([]string{subdir, rest}, "/"), "/")
+}
+
+// trimRepositoryURL returns the repository name from the URL. e.g.) https://github.com/owner/repo.git => github.com/owner/repo
+func trimRepositoryURL(repoURL string) string {
+	if idx := strings.Index(repoURL, "://"); idx >= 0 {
+		repoURL = repoURL[idx+len("://"):]
+	}
+	if idx := strings.Index(repoURL, "@"); idx >= 0 && idx < strings.Index(repoURL+"/", "/") {
+		// user info such as git@
+		repoURL = repoURL[idx+1:]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git")
+}
@@ -0,0 +1,161 @@
+package modrank
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestGoImportGolden resolves the module by the go-import meta tags of the pages in testdata/goimport/*.txt
+// and compares the result with the golden file. Each case file has the following format:
+//
+//	module: example.com/mod
+//	-- example.com/mod --
+//	<html>...</html>
+//
+// The section name is the host and path of the page served for the request.
+func TestGoImportGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "goimport", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		t.Run(name, func(t *testing.T) {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			modulePath, pages := parseGoImportCase(t, string(content))
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				page, exists := pages[req.Host+req.URL.Path]
+				if !exists {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(page))
+			}))
+			defer server.Close()
+
+			hc := &http.Client{Transport: &goImportTestTransport{addr: server.Listener.Addr().String()}}
+			proxy := &goProxy{headers: make(map[string]http.Header)}
+			root, err := getHostedRepositoryByGoImportMetaTag(context.Background(), hc, proxy, modulePath)
+			got := formatHostedRepositoryRoot(root, err)
+
+			golden := strings.TrimSuffix(file, ".txt") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(expected) {
+				t.Fatalf("unexpected result:\ngot:\n%s\nexpected:\n%s", got, expected)
+			}
+		})
+	}
+}
+
+func parseGoImportCase(t *testing.T, content string) (string, map[string]string) {
+	t.Helper()
+
+	header, body, _ := strings.Cut(content, "\n")
+	modulePath, found := strings.CutPrefix(header, "module: ")
+	if !found {
+		t.Fatalf("module path is not found: %s", header)
+	}
+	pages := make(map[string]string)
+	var (
+		name string
+		page strings.Builder
+	)
+	for _, line := range strings.SplitAfter(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "-- ") && strings.HasSuffix(trimmed, " --") {
+			if name != "" {
+				pages[name] = page.String()
+			}
+			name = strings.TrimSuffix(strings.TrimPrefix(trimmed, "-- "), " --")
+			page.Reset()
+			continue
+		}
+		page.WriteString(line)
+	}
+	if name != "" {
+		pages[name] = page.String()
+	}
+	return modulePath, pages
+}
+
+func formatHostedRepositoryRoot(root *HostedRepositoryRoot, err error) string {
+	if err != nil {
+		return fmt.Sprintf("error: %s\n", err)
+	}
+	return fmt.Sprintf(
+		"prefix: %s\nrepository: %s\nvcs: %s\nsubdirectory: %s\n",
+		root.Prefix, root.Repository, root.VCS, root.Subdirectory,
+	)
+}
+
+// goImportTestTransport sends all requests to the test server keeping the original host in the Host header.
+type goImportTestTransport struct {
+	addr string
+}
+
+func (t *goImportTestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = "http"
+	r.URL.Host = t.addr
+	r.Host = req.URL.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestHostedRepositoryRootWithoutNetwork(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{
+			name:     "github.com/cncf/udpa/go",
+			expected: "prefix: github.com/cncf/udpa\nrepository: github.com/cncf/udpa\nvcs: git\nsubdirectory: go\n",
+		},
+		{
+			name:     "github.com/goccy/go-yaml/v2",
+			expected: "prefix: github.com/goccy/go-yaml\nrepository: github.com/goccy/go-yaml\nvcs: git\nsubdirectory: \n",
+		},
+		{
+			name:     "gopkg.in/yaml.v3",
+			expected: "prefix: gopkg.in/yaml.v3\nrepository: github.com/go-yaml/yaml\nvcs: git\nsubdirectory: \n",
+		},
+		{
+			name:     "gopkg.in/src-d/go-git.v4/sub",
+			expected: "prefix: gopkg.in/src-d/go-git.v4\nrepository: github.com/src-d/go-git\nvcs: git\nsubdirectory: sub\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := getHostedRepositoryByGoPkgIn(test.name)
+			if root == nil && err == nil {
+				root, err = getHostedRepositoryByKnownHost(test.name)
+			}
+			if root == nil && err == nil {
+				t.Fatal("failed to resolve hosted repository")
+			}
+			if got := formatHostedRepositoryRoot(root, err); got != test.expected {
+				t.Fatalf("unexpected result:\ngot:\n%s\nexpected:\n%s", got, test.expected)
+			}
+		})
+	}
+}
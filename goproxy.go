@@ -0,0 +1,162 @@
+package modrank
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+const defaultGoProxy = "https://proxy.golang.org,direct"
+
+// errGoProxyNotFound is returned when the proxy responds with 404 Not Found or 410 Gone.
+var errGoProxyNotFound = errors.New("module is not found in the proxy")
+
+// goProxyEntry is the element of GOPROXY list.
+type goProxyEntry struct {
+	// url is the proxy URL, or direct or off.
+	url string
+	// fallbackOnError whether to try the next entry on any error.
+	// It is true if the entry is followed by a pipe, otherwise the next entry is tried only if the module is not found.
+	fallbackOnError bool
+}
+
+// goProxy resolves the hosted repository of the Go module by the Origin data of the module proxy
+// with the same semantics as GOPROXY, GOPRIVATE and GONOPROXY of the go command.
+type goProxy struct {
+	entries []*goProxyEntry
+	noProxy string
+	headers map[string]http.Header
+}
+
+// newGoProxyFromEnv creates goProxy by GOPROXY, GOPRIVATE and GONOPROXY environment variables.
+func newGoProxyFromEnv() *goProxy {
+	goproxy := os.Getenv("GOPROXY")
+	if goproxy == "" {
+		goproxy = defaultGoProxy
+	}
+	noProxy := os.Getenv("GONOPROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("GOPRIVATE")
+	}
+	return &goProxy{
+		entries: parseGoProxy(goproxy),
+		noProxy: noProxy,
+		headers: make(map[string]http.Header),
+	}
+}
+
+// parseGoProxy parses GOPROXY list separated by comma or pipe.
+func parseGoProxy(v string) []*goProxyEntry {
+	var entries []*goProxyEntry
+	for v != "" {
+		var (
+			elem            string
+			fallbackOnError bool
+		)
+		if idx := strings.IndexAny(v, ",|"); idx >= 0 {
+			elem = v[:idx]
+			fallbackOnError = v[idx] == '|'
+			v = v[idx+1:]
+		} else {
+			elem = v
+			v = ""
+		}
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+		entries = append(entries, &goProxyEntry{
+			url:             strings.TrimSuffix(elem, "/"),
+			fallbackOnError: fallbackOnError,
+		})
+	}
+	return entries
+}
+
+// resolve returns the hosted repository of the module by the proxies.
+// If the module matches GONOPROXY patterns or no proxy has the Origin data, returns nil.
+func (p *goProxy) resolve(ctx context.Context, hc *http.Client, name string) (*HostedRepositoryRoot, error) {
+	if p.noProxy != "" && module.MatchPrefixPatterns(p.noProxy, name) {
+		return nil, nil
+	}
+	var lastErr error
+	for _, entry := range p.entries {
+		if entry.url == "direct" || entry.url == "off" {
+			// the hosted repository is resolved by the other ways instead of the proxy.
+			return nil, nil
+		}
+		repo, err := p.resolveByProxy(ctx, hc, entry.url, name)
+		if err == nil {
+			return repo, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if !errors.Is(err, errGoProxyNotFound) && !entry.fallbackOnError {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+func (p *goProxy) resolveByProxy(ctx context.Context, hc *http.Client, proxyURL, name string) (*HostedRepositoryRoot, error) {
+	escaped, err := module.EscapePath(name)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s/@latest", proxyURL, escaped), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range p.headers[proxyURL] {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, errGoProxyNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to call %s: %s: %s", proxyURL, resp.Status, string(body))
+	}
+
+	var v struct {
+		Origin struct {
+			VCS    string
+			URL    string
+			Subdir string
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode %s module: %w", name, err)
+	}
+	if v.Origin.URL == "" {
+		// the proxy doesn't provide the Origin data.
+		return nil, errGoProxyNotFound
+	}
+	subdir := strings.Trim(v.Origin.Subdir, "/")
+	prefix := name
+	if subdir != "" {
+		prefix = strings.TrimSuffix(name, "/"+subdir)
+	}
+	return &HostedRepositoryRoot{
+		Prefix:       prefix,
+		Repository:   trimRepositoryURL(v.Origin.URL),
+		VCS:          v.Origin.VCS,
+		Subdirectory: subdir,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package modrank
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGoProxy(t *testing.T) {
+	var called []string
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = append(called, "notfound")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer notFound.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = append(called, "broken")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = append(called, "private")
+		if req.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// the module path is escaped by the case-encoding.
+		if req.URL.Path != "/example.com/!owner/mod/@latest" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Version": "v1.0.0", "Origin": {"VCS": "git", "URL": "https://git.example.com/Owner/mod"}}`))
+	}))
+	defer private.Close()
+
+	tests := []struct {
+		name     string
+		goproxy  string
+		noProxy  string
+		expected string
+		isErr    bool
+		called   []string
+	}{
+		{
+			name:     "fallback after not found",
+			goproxy:  notFound.URL + "," + private.URL,
+			expected: "git.example.com/Owner/mod",
+			called:   []string{"notfound", "private"},
+		},
+		{
+			name:     "fallback after error with pipe",
+			goproxy:  broken.URL + "|" + private.URL,
+			expected: "git.example.com/Owner/mod",
+			called:   []string{"broken", "private"},
+		},
+		{
+			name:    "stop after error with comma",
+			goproxy: broken.URL + "," + private.URL,
+			isErr:   true,
+			called:  []string{"broken"},
+		},
+		{
+			name:    "direct",
+			goproxy: notFound.URL + ",direct," + private.URL,
+			called:  []string{"notfound"},
+		},
+		{
+			name:    "off",
+			goproxy: "off",
+		},
+		{
+			name:    "private module",
+			goproxy: private.URL,
+			noProxy: "example.com/Owner",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			called = nil
+			p := &goProxy{
+				entries: parseGoProxy(test.goproxy),
+				noProxy: test.noProxy,
+				headers: map[string]http.Header{
+					private.URL: {"Authorization": []string{"Bearer token"}},
+				},
+			}
+			got, err := p.resolve(context.Background(), http.DefaultClient, "example.com/Owner/mod")
+			if test.isErr != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var repo string
+			if got != nil {
+				repo = got.Repository
+			}
+			if repo != test.expected {
+				t.Fatalf("unexpected hosted repository: %q", repo)
+			}
+			if len(called) != len(test.called) {
+				t.Fatalf("unexpected proxies are called: %v", called)
+			}
+			for idx := range called {
+				if called[idx] != test.called[idx] {
+					t.Fatalf("unexpected proxies are called: %v", called)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package modrank
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/goccy/go-modrank/repository"
+)
+
+// RepositoryHost is the interface to access the API of the service hosting repositories such as GitHub or GitLab.
+// It is used to skip cloning archived repositories, repositories without go.mod and
+// repositories whose HEAD commit has already been scanned.
+type RepositoryHost interface {
+	// Match reports whether the repository is hosted by this service.
+	Match(repo *repository.Repository) bool
+	// CreateRepositoryCache prefetches the status of the repositories.
+	// IsArchived, GetHeadCommit and ExistsGoMod can be used only for the prefetched repositories.
+	CreateRepositoryCache(ctx context.Context, repos []*repository.Repository) error
+	// IsArchived returns whether the repository is archived.
+	// If the repository doesn't exist, returns the error that matches ErrRepositoryNotFound by errors.Is.
+	IsArchived(ctx context.Context, repo *repository.Repository) (bool, error)
+	// GetHeadCommit returns the head commit hash of the default branch.
+	// If the repository is empty, returns empty string.
+	// If the repository doesn't exist, returns the error that matches ErrRepositoryNotFound by errors.Is.
+	GetHeadCommit(ctx context.Context, repo *repository.Repository) (string, error)
+	// ExistsGoMod returns whether the default branch has go.mod file.
+	ExistsGoMod(ctx context.Context, repo *repository.Repository) (bool, error)
+}
+
+// canonicalNameReporter is implemented by RepositoryHost that can report the current name of the renamed repository.
+type canonicalNameReporter interface {
+	// CanonicalName returns the current name including the host name of the renamed or transferred repository.
+	// If the repository isn't renamed, returns empty string.
+	CanonicalName(repo *repository.Repository) string
+}
+
+// RepositoryCredential provides the credential to clone the repositories hosted by the service by HTTPS.
+// GitHubClient, GitLabClient, GiteaClient and BitbucketClient implement it.
+type RepositoryCredential interface {
+	// Match reports whether the repository is hosted by this service.
+	Match(repo *repository.Repository) bool
+	// CloneAuth returns the token issuer and the username used with the token.
+	// If the username is empty, the default username of the repository package is used.
+	CloneAuth() (repository.TokenIssuer, string)
+}
+
+// RepositoryHostAuth returns the option to clone the repository with the credential of the service hosting the repository.
+// If no credential matches the repository, the credential is not set,
+// so that the token of one hosting service is never sent to the other services.
+func RepositoryHostAuth(creds ...RepositoryCredential) repository.Option {
+	return func(repo *repository.Repository) error {
+		for _, cred := range creds {
+			if !cred.Match(repo) {
+				continue
+			}
+			issuer, username := cred.CloneAuth()
+			if err := repository.WithAuthToken(issuer)(repo); err != nil {
+				return err
+			}
+			return repository.WithAuthUsername(username)(repo)
+		}
+		return nil
+	}
+}
+
+// ErrRepositoryNotFound is the error for the repository that is deleted or can't be accessed by the token.
+var ErrRepositoryNotFound = errors.New("repository not found")
+
+// RepositoryError is the error of the specific repository reported by the hosting service API.
+// It doesn't affect other repositories requested together.
+type RepositoryError struct {
+	// NameWithOwner is the repository name. e.g.) goccy/go-modrank
+	NameWithOwner string
+	// Type is the error type reported by the API. e.g.) NOT_FOUND, FORBIDDEN
+	Type    string
+	Message string
+}
+
+func (e *RepositoryError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.NameWithOwner, e.Type, e.Message)
+}
+
+func (e *RepositoryError) Is(target error) bool {
+	return target == ErrRepositoryNotFound && e.Type == repositoryNotFoundErrorType
+}
+
+// repositoryNotFoundErrorType is the type of RepositoryError for the repository that doesn't exist.
+const repositoryNotFoundErrorType = "NOT_FOUND"
+
+// newRepositoryError converts the error of the REST API of the repository to RepositoryError,
+// so that the repository that can't be accessed doesn't stop prefetching other repositories.
+// If the error is not caused by the repository such as the transport error, the context error,
+// the invalid credential or the rate limit, returns nil.
+func newRepositoryError(nameWithOwner string, err error) *RepositoryError {
+	if errors.Is(err, errAPINotFound) {
+		return &RepositoryError{NameWithOwner: nameWithOwner, Type: repositoryNotFoundErrorType, Message: err.Error()}
+	}
+	var statusErr *apiStatusError
+	if !errors.As(err, &statusErr) {
+		return nil
+	}
+	switch code := statusErr.statusCode; {
+	case code == http.StatusUnauthorized, code == http.StatusTooManyRequests, code < 400, code >= 500:
+		return nil
+	}
+	return &RepositoryError{
+		NameWithOwner: nameWithOwner,
+		Type:          strings.ToUpper(strings.ReplaceAll(http.StatusText(statusErr.statusCode), " ", "_")),
+		Message:       err.Error(),
+	}
+}
+
+var (
+	_ RepositoryHost        = new(githubHost)
+	_ canonicalNameReporter = new(githubHost)
+)
+
+// githubHost is the RepositoryHost implementation by GitHubClient.
+type githubHost struct {
+	client *GitHubClient
+}
+
+func (h *githubHost) setDefaultHTTPClient(hc *http.Client) {
+	h.client.setDefaultHTTPClient(hc)
+}
+
+func (h *githubHost) Match(repo *repository.Repository) bool {
+	return h.client.Match(repo)
+}
+
+func (h *githubHost) CreateRepositoryCache(ctx context.Context, repos []*repository.Repository) error {
+	return h.client.CreateGitHubRepositoryCache(ctx, repos)
+}
+
+func (h *githubHost) IsArchived(ctx context.Context, repo *repository.Repository) (bool, error) {
+	return h.client.IsArchived(ctx, repo.Owner(), repo.Name())
+}
+
+func (h *githubHost) GetHeadCommit(ctx context.Context, repo *repository.Repository) (string, error) {
+	return h.client.GetHeadCommit(ctx, repo.Owner(), repo.Name())
+}
+
+func (h *githubHost) CanonicalName(repo *repository.Repository) string {
+	owner, name := h.client.CanonicalName(repo.Owner(), repo.Name())
+	if owner == repo.Owner() && name == repo.Name() {
+		return ""
+	}
+	return repo.Host() + "/" + owner + "/" + name
+}
+
+func (h *githubHost) ExistsGoMod(ctx context.Context, repo *repository.Repository) (bool, error) {
+	return h.client.ExistsGoMod(ctx, repo.Owner(), repo.Name())
+}
+
+// findRepositoryHost returns the RepositoryHost hosting the repository.
+// If no host matches, returns nil.
+func (r *ModRank) findRepositoryHost(repo *repository.Repository) RepositoryHost {
+	for _, host := range r.repoHosts {
+		if host.Match(repo) {
+			return host
+		}
+	}
+	return nil
+}
+
+// createRepositoryCache prefetches the status of the repositories by each RepositoryHost.
+func (r *ModRank) createRepositoryCache(ctx context.Context, repos []*repository.Repository) error {
+	for _, host := range r.repoHosts {
+		var hostRepos []*repository.Repository
+		for _, repo := range repos {
+			if r.findRepositoryHost(repo) == host {
+				hostRepos = append(hostRepos, repo)
+			}
+		}
+		if len(hostRepos) == 0 {
+			continue
+		}
+		if err := host.CreateRepositoryCache(ctx, hostRepos); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hostAPIConcurrency is the number of concurrent requests to create the repository cache
+// for hosting services that don't provide the way to get the status of multiple repositories by one request.
+const hostAPIConcurrency = 8
+
+// errAPINotFound is returned when the hosting service API responds with 404 Not Found.
+var errAPINotFound = errors.New("not found")
+
+// apiStatusError is returned when the hosting service API responds with the unexpected status code.
+type apiStatusError struct {
+	url        string
+	status     string
+	statusCode int
+	body       string
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("failed to call %s: %s: %s", e.url, e.status, e.body)
+}
+
+// httpClientHolder holds the HTTP client of the API client.
+// It is embedded in API clients so that ModRank can inject the client specified by WithHTTPClient() option.
+type httpClientHolder struct {
+	httpClient *http.Client
+}
+
+// SetHTTPClient specify the HTTP client to call the API.
+// If it is not specified, the client of WithHTTPClient() option or http.DefaultClient is used.
+func (h *httpClientHolder) SetHTTPClient(hc *http.Client) {
+	h.httpClient = hc
+}
+
+// setDefaultHTTPClient sets the HTTP client only if SetHTTPClient has not been called.
+func (h *httpClientHolder) setDefaultHTTPClient(hc *http.Client) {
+	if h.httpClient == nil {
+		h.httpClient = hc
+	}
+}
+
+func (h *httpClientHolder) client() *http.Client {
+	if h.httpClient == nil {
+		return http.DefaultClient
+	}
+	return h.httpClient
+}
+
+// defaultHTTPClientSetter is implemented by API clients embedding httpClientHolder.
+type defaultHTTPClientSetter interface {
+	setDefaultHTTPClient(*http.Client)
+}
+
+// getJSON calls GET method of the hosting service API with the authorization header and decodes the response to v.
+// It returns the response header to handle pagination.
+func getJSON(ctx context.Context, hc *http.Client, reqURL, authorization string, v any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errAPINotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &apiStatusError{url: reqURL, status: resp.Status, statusCode: resp.StatusCode, body: string(body)}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, err
+	}
+	return resp.Header, nil
+}
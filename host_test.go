@@ -0,0 +1,63 @@
+package modrank_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/goccy/go-modrank"
+	"github.com/goccy/go-modrank/repository"
+)
+
+func TestRepositoryHostAuth(t *testing.T) {
+	ctx := context.Background()
+	githubClient := modrank.NewGitHubClient(ctx, modrank.GitStaticAccessToken("github-token"))
+	gitlabClient, err := modrank.NewGitLabClient(ctx, "https://gitlab.example.com", modrank.GitLabStaticAccessToken("gitlab-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	giteaClient, err := modrank.NewGiteaClient(ctx, "https://gitea.example.com", modrank.GiteaStaticAccessToken("gitea-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitbucketClient := modrank.NewBitbucketClient(ctx, "", modrank.BitbucketStaticAccessToken("bitbucket-token"))
+	tests := []struct {
+		url  string
+		auth *repository.BasicAuth
+	}{
+		{url: "https://github.com/goccy/go-modrank.git", auth: &repository.BasicAuth{Username: "x-access-token", Password: "github-token"}},
+		{url: "https://gitlab.example.com/group/project.git", auth: &repository.BasicAuth{Username: "oauth2", Password: "gitlab-token"}},
+		{url: "https://gitea.example.com/org/repo.git", auth: &repository.BasicAuth{Username: "x-access-token", Password: "gitea-token"}},
+		{url: "https://bitbucket.org/workspace/repo.git", auth: &repository.BasicAuth{Username: "x-token-auth", Password: "bitbucket-token"}},
+		{url: "https://example.com/owner/repo.git"},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			var got *repository.BasicAuth
+			repo, err := repository.New(
+				test.url,
+				modrank.RepositoryHostAuth(githubClient, gitlabClient, giteaClient, bitbucketClient),
+				repository.WithCloner(&TestCloner{
+					clone: func(_ context.Context, _, _ string, auth *repository.BasicAuth) error {
+						got = auth
+						return nil
+					},
+				}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.Clone(ctx, t.TempDir()); err != nil {
+				t.Fatal(err)
+			}
+			if test.auth == nil {
+				if got != nil {
+					t.Fatalf("the credential must not be sent to %s: %+v", repo.Host(), got)
+				}
+				return
+			}
+			if got == nil || *got != *test.auth {
+				t.Fatalf("unexpected credential: expected %+v but got %+v", test.auth, got)
+			}
+		})
+	}
+}
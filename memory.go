@@ -0,0 +1,326 @@
+package modrank
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+var (
+	_ Storage                 = new(MemoryStorage)
+	_ HostedRepositoryStorage = new(MemoryStorage)
+)
+
+// MemoryStorage is the goroutine-safe storage keeping all data in memory.
+// It is useful for tests, one-off runs and embedding because nothing is shared with other processes.
+// If the snapshot file is specified, the data is restored from the file at creation and saved to it by Close.
+type MemoryStorage struct {
+	mu           sync.RWMutex
+	repos        map[string]*RepositoryStatus
+	mods         map[string]*memoryGoModule
+	hostedRepos  map[string]*HostedRepositoryMapping
+	snapshotPath string
+
+	// graph is the dependency graph built from mods. It is built again after mods are updated.
+	graph map[string]*GoModule
+}
+
+type MemoryStorageOption func(*MemoryStorage)
+
+// MemorySnapshotPath specify the file path to restore the data from and save the data to by Close.
+// If the file doesn't exist, the storage starts with empty data.
+func MemorySnapshotPath(path string) MemoryStorageOption {
+	return func(s *MemoryStorage) {
+		s.snapshotPath = path
+	}
+}
+
+// memoryGoModule is the stored Go module. The edges are kept by the IDs of the referred modules.
+type memoryGoModule struct {
+	ID               string   `json:"id"`
+	Repository       string   `json:"repository"`
+	GoModPath        string   `json:"goModPath"`
+	Name             string   `json:"name"`
+	Version          string   `json:"version"`
+	HostedRepository string   `json:"hostedRepository"`
+	Subdirectory     string   `json:"subdirectory,omitempty"`
+	IsRoot           bool     `json:"isRoot"`
+	Refers           []string `json:"refers,omitempty"`
+}
+
+// memorySnapshot is the format of the snapshot file.
+type memorySnapshot struct {
+	Repositories       []*RepositoryStatus        `json:"repositories"`
+	GoModules          []*memoryGoModule          `json:"goModules"`
+	HostedRepositories []*HostedRepositoryMapping `json:"hostedRepositories"`
+}
+
+func NewMemoryStorage(opts ...MemoryStorageOption) (*MemoryStorage, error) {
+	s := &MemoryStorage{
+		repos:       make(map[string]*RepositoryStatus),
+		mods:        make(map[string]*memoryGoModule),
+		hostedRepos: make(map[string]*HostedRepositoryMapping),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.snapshotPath != "" {
+		if err := s.restore(s.snapshotPath); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *MemoryStorage) restore(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var snapshot memorySnapshot
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return fmt.Errorf("failed to restore the snapshot %s: %w", path, err)
+	}
+	for _, st := range snapshot.Repositories {
+		s.repos[st.NameWithOwner] = st
+	}
+	for _, mod := range snapshot.GoModules {
+		s.mods[mod.ID] = mod
+	}
+	for _, mapping := range snapshot.HostedRepositories {
+		s.hostedRepos[mapping.ModulePath] = mapping
+	}
+	return nil
+}
+
+// Save saves the data to the snapshot file. If the snapshot file isn't specified, it does nothing.
+// The data is written to the temporary file and renamed so that the snapshot is never broken halfway.
+func (s *MemoryStorage) Save() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	s.mu.RLock()
+	snapshot := &memorySnapshot{
+		Repositories:       make([]*RepositoryStatus, 0, len(s.repos)),
+		GoModules:          make([]*memoryGoModule, 0, len(s.mods)),
+		HostedRepositories: make([]*HostedRepositoryMapping, 0, len(s.hostedRepos)),
+	}
+	for _, st := range s.repos {
+		snapshot.Repositories = append(snapshot.Repositories, st)
+	}
+	for _, mod := range s.mods {
+		snapshot.GoModules = append(snapshot.GoModules, mod)
+	}
+	for _, mapping := range s.hostedRepos {
+		snapshot.HostedRepositories = append(snapshot.HostedRepositories, mapping)
+	}
+	b, err := json.Marshal(snapshot)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.snapshotPath), filepath.Base(s.snapshotPath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.snapshotPath)
+}
+
+// Close saves the data to the snapshot file if it is specified.
+func (s *MemoryStorage) Close() error {
+	return s.Save()
+}
+
+func (s *MemoryStorage) CreateRepositoryStorageIfNotExists(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStorage) FindRepositoryByName(ctx context.Context, nameWithOwner string) (*RepositoryStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st, exists := s.repos[nameWithOwner]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	v := *st
+	return &v, nil
+}
+
+func (s *MemoryStorage) InsertOrUpdateRepository(ctx context.Context, st *RepositoryStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := *st
+	s.repos[st.NameWithOwner] = &v
+	return nil
+}
+
+func (s *MemoryStorage) CreateGoModuleStorageIfNotExists(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStorage) FindRootGoModules(ctx context.Context) ([]*GoModule, error) {
+	// the roots are found in the same critical section as the graph so that they are not paired with the modules updated in between.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	graph := s.dependencyGraph()
+	var roots []*GoModule
+	for id, mod := range graph {
+		if stored, exists := s.mods[id]; exists && stored.IsRoot {
+			roots = append(roots, mod)
+		}
+	}
+	sortGoModules(roots)
+	return roots, nil
+}
+
+func (s *MemoryStorage) FindGoModuleByID(ctx context.Context, id string) (*GoModule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mod, exists := s.dependencyGraph()[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	return mod, nil
+}
+
+// dependencyGraph returns the modules by ID referring each other.
+// The graph is shared by the callers until the modules are updated.
+// The caller must hold the write lock of mu because the graph is cached.
+func (s *MemoryStorage) dependencyGraph() map[string]*GoModule {
+	if s.graph != nil {
+		return s.graph
+	}
+	graph := make(map[string]*GoModule, len(s.mods))
+	for id, mod := range s.mods {
+		graph[id] = &GoModule{
+			ID:               mod.ID,
+			Repository:       mod.Repository,
+			GoModPath:        mod.GoModPath,
+			Name:             mod.Name,
+			Version:          mod.Version,
+			HostedRepository: mod.HostedRepository,
+			Subdirectory:     mod.Subdirectory,
+		}
+	}
+	for id, mod := range s.mods {
+		caller := graph[id]
+		for _, refID := range mod.Refers {
+			callee, exists := graph[refID]
+			if !exists {
+				continue
+			}
+			caller.Refers = append(caller.Refers, callee)
+			callee.Referers = append(callee.Referers, caller)
+		}
+	}
+	for _, mod := range graph {
+		sortGoModules(mod.Refers)
+		sortGoModules(mod.Referers)
+	}
+	s.graph = graph
+	return graph
+}
+
+func (s *MemoryStorage) InsertOrUpdateGoModules(ctx context.Context, nameWithOwner string, mods []*GoModule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the edges from the modules no longer used by the repository are also deleted.
+	for _, mod := range s.mods {
+		if mod.Repository == nameWithOwner {
+			mod.Refers = nil
+		}
+	}
+
+	for _, mod := range mods {
+		refers := make([]string, 0, len(mod.Refers))
+		for _, ref := range mod.Refers {
+			refers = append(refers, ref.ID)
+		}
+		sort.Strings(refers)
+		s.mods[mod.ID] = &memoryGoModule{
+			ID:               mod.ID,
+			Repository:       mod.Repository,
+			GoModPath:        mod.GoModPath,
+			Name:             mod.Name,
+			Version:          mod.Version,
+			HostedRepository: mod.HostedRepository,
+			Subdirectory:     mod.Subdirectory,
+			IsRoot:           mod.IsRoot(),
+			Refers:           refers,
+		}
+	}
+	s.graph = nil
+	return nil
+}
+
+func (s *MemoryStorage) CreateHostedRepositoryStorageIfNotExists(ctx context.Context) error {
+	return nil
+}
+
+// FindHostedRepository returns nil without error if the mapping of the module is not stored.
+func (s *MemoryStorage) FindHostedRepository(ctx context.Context, modulePath string) (*HostedRepositoryMapping, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mapping, exists := s.hostedRepos[modulePath]
+	if !exists {
+		return nil, nil
+	}
+	v := *mapping
+	return &v, nil
+}
+
+func (s *MemoryStorage) FindHostedRepositories(ctx context.Context) ([]*HostedRepositoryMapping, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mappings := make([]*HostedRepositoryMapping, 0, len(s.hostedRepos))
+	for _, mapping := range s.hostedRepos {
+		v := *mapping
+		mappings = append(mappings, &v)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].ModulePath < mappings[j].ModulePath
+	})
+	return mappings, nil
+}
+
+func (s *MemoryStorage) InsertOrUpdateHostedRepository(ctx context.Context, mapping *HostedRepositoryMapping) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := *mapping
+	s.hostedRepos[mapping.ModulePath] = &v
+	return nil
+}
+
+func (s *MemoryStorage) DeleteHostedRepository(ctx context.Context, modulePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.hostedRepos, modulePath)
+	return nil
+}
@@ -0,0 +1,249 @@
+package modrank
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MigratableStorage is the storage whose schema is upgraded by the ordered migrations.
+// The applied versions are recorded in the schema version table of the database,
+// so each migration is applied only once even if the database is used for a long time.
+type MigratableStorage interface {
+	// Migrate applies the pending migrations in order.
+	Migrate(ctx context.Context) error
+	// MigrationStatus returns all migrations known by this version of go-modrank and the database.
+	MigrationStatus(ctx context.Context) ([]*MigrationStatus, error)
+}
+
+// MigrationStatus is the state of the migration of the storage schema.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	// AppliedAt is the time when the migration is applied. It is zero if the migration is pending.
+	AppliedAt time.Time
+	// Unknown whether the migration is applied by the newer version of go-modrank.
+	Unknown bool
+}
+
+// Applied returns whether the migration is applied to the database.
+func (s *MigrationStatus) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+// PendingMigrations returns the migrations which are not applied yet.
+func PendingMigrations(statuses []*MigrationStatus) []*MigrationStatus {
+	var pending []*MigrationStatus
+	for _, st := range statuses {
+		if !st.Applied() {
+			pending = append(pending, st)
+		}
+	}
+	return pending
+}
+
+// sqlExecutor is the common interface of *sql.DB, *sql.Conn and *sql.Tx used by the migrations.
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// sqlMigration is the migration of the SQL database.
+// The migration must be idempotent because the database created before the schema version table exists
+// already has a part of the schema.
+type sqlMigration struct {
+	version     int
+	description string
+	migrate     func(ctx context.Context, tx *sql.Tx) error
+}
+
+// execStatements returns the migration executing the statements in order.
+func execStatements(stmts ...string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// sqlMigrator applies the migrations of the SQL database and records the applied versions.
+type sqlMigrator struct {
+	db         *sql.DB
+	migrations []*sqlMigration
+
+	// createVersionTable, existsVersionTable, selectVersions and insertVersion are the queries of the schema version table
+	// in the dialect of the database. existsVersionTable returns the number of the schema version tables.
+	// insertVersion takes the version, the description and the unix time applied at.
+	createVersionTable string
+	existsVersionTable string
+	selectVersions     string
+	insertVersion      string
+
+	// lock and unlock serialize the migrations by multiple processes sharing the database if specified.
+	lock   func(ctx context.Context, conn *sql.Conn) error
+	unlock func(ctx context.Context, conn *sql.Conn) error
+	// lockTx is executed first in the transaction of each migration to take the write lock if specified.
+	// It is used by the database without the lock of the session (e.g. SQLite).
+	lockTx string
+
+	migrated bool
+	mu       sync.Mutex
+}
+
+// ensure applies the pending migrations only once in the process.
+func (m *sqlMigrator) ensure(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.migrated {
+		return nil
+	}
+	return m.migrateLocked(ctx)
+}
+
+func (m *sqlMigrator) migrate(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.migrateLocked(ctx)
+}
+
+func (m *sqlMigrator) migrateLocked(ctx context.Context) error {
+	// the lock is held by the connection, so the migrations run on the same connection.
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.lock != nil {
+		if err := m.lock(ctx, conn); err != nil {
+			return fmt.Errorf("failed to lock the schema migration: %w", err)
+		}
+		defer func() { _ = m.unlock(context.WithoutCancel(ctx), conn) }()
+	}
+	if _, err := conn.ExecContext(ctx, m.createVersionTable); err != nil {
+		return err
+	}
+	statuses, err := m.statuses(ctx, conn)
+	if err != nil {
+		return err
+	}
+	latest := m.migrations[len(m.migrations)-1].version
+	for _, st := range statuses {
+		if st.Unknown {
+			return fmt.Errorf("the database schema version %d is newer than the supported version %d", st.Version, latest)
+		}
+	}
+	for _, st := range PendingMigrations(statuses) {
+		if err := m.apply(ctx, conn, st.Version); err != nil {
+			return fmt.Errorf("failed to apply the migration %d (%s): %w", st.Version, st.Description, err)
+		}
+	}
+	m.migrated = true
+	return nil
+}
+
+func (m *sqlMigrator) apply(ctx context.Context, conn *sql.Conn, version int) error {
+	var mig *sqlMigration
+	for _, v := range m.migrations {
+		if v.version == version {
+			mig = v
+			break
+		}
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if m.lockTx != "" {
+		if _, err := tx.ExecContext(ctx, m.lockTx); err != nil {
+			return fmt.Errorf("failed to lock the schema migration: %w", err)
+		}
+	}
+	// read the applied versions again in the transaction
+	// because the other process may apply the migration after reading the statuses.
+	statuses, err := m.statuses(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for _, st := range statuses {
+		if st.Version == version && st.Applied() {
+			return nil
+		}
+	}
+	if err := mig.migrate(ctx, tx); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, m.insertVersion, mig.version, mig.description, time.Now().Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// status returns the statuses of the migrations without writing to the database.
+// If the schema version table doesn't exist yet, all migrations are pending.
+func (m *sqlMigrator) status(ctx context.Context) ([]*MigrationStatus, error) {
+	var num int
+	if err := m.db.QueryRowContext(ctx, m.existsVersionTable).Scan(&num); err != nil {
+		return nil, err
+	}
+	if num == 0 {
+		return m.mergeStatuses(nil), nil
+	}
+	return m.statuses(ctx, m.db)
+}
+
+// statuses returns the statuses of the known migrations and the applied migrations unknown by this version in order.
+func (m *sqlMigrator) statuses(ctx context.Context, exec sqlExecutor) ([]*MigrationStatus, error) {
+	rows, err := exec.QueryContext(ctx, m.selectVersions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]*MigrationStatus)
+	for rows.Next() {
+		var (
+			st        = &MigrationStatus{}
+			appliedAt int64
+		)
+		if err := rows.Scan(&st.Version, &st.Description, &appliedAt); err != nil {
+			return nil, err
+		}
+		st.AppliedAt = time.Unix(appliedAt, 0)
+		applied[st.Version] = st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return m.mergeStatuses(applied), nil
+}
+
+// mergeStatuses returns the statuses of the known migrations with the applied time and the applied migrations unknown by this version in order.
+func (m *sqlMigrator) mergeStatuses(applied map[int]*MigrationStatus) []*MigrationStatus {
+	statuses := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := &MigrationStatus{Version: mig.version, Description: mig.description}
+		if v, exists := applied[mig.version]; exists {
+			st.AppliedAt = v.AppliedAt
+			delete(applied, mig.version)
+		}
+		statuses = append(statuses, st)
+	}
+	for _, st := range applied {
+		st.Unknown = true
+		statuses = append(statuses, st)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses
+}
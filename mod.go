@@ -1,18 +1,16 @@
 package modrank
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/gocolly/colly"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/goccy/go-modrank/repository"
 )
@@ -21,7 +19,7 @@ import (
 type GoModule struct {
 	// ID to uniquely identify a GoModule, hashed from Repository/GoModPath/Name/Version.
 	ID string
-	// Repository name of the repository using this Go module.
+	// Repository name of the repository using this Go module. e.g.) github.com/goccy/go-modrank
 	Repository string
 	// GoModPath path to the go.mod on the repository using this Go module.
 	GoModPath string
@@ -31,6 +29,8 @@ type GoModule struct {
 	Version string
 	// HostedRepository is the hosted repository name of the Go module.
 	HostedRepository string
+	// Subdirectory is the directory containing the Go module within the hosted repository. e.g.) go
+	Subdirectory string
 	// Refers is the list of Modules this Go module depends on.
 	Refers []*GoModule
 	// Referers is th list of Modules on which this Go module is dependent.
@@ -94,137 +94,141 @@ func newGoModule(repo *repository.Repository, goModPath, rootModName, modPath st
 		// keyword
 		return nil, nil
 	}
-	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s/%s", repo.NameWithOwner(), goModPath, name, ver)))
 	node := &GoModule{
-		ID:               hex.EncodeToString(hash[:]),
-		Repository:       repo.NameWithOwner(),
-		GoModPath:        goModPath,
-		Name:             name,
-		Version:          ver,
-		HostedRepository: getHostedRepositoryByNameWithCache(name),
-		referMap:         make(map[*GoModule]struct{}),
-		refererMap:       make(map[*GoModule]struct{}),
+		ID:         goModuleID(repo.FullName(), goModPath, name, ver),
+		Repository: repo.FullName(),
+		GoModPath:  goModPath,
+		Name:       name,
+		Version:    ver,
+		referMap:   make(map[*GoModule]struct{}),
+		refererMap: make(map[*GoModule]struct{}),
 	}
 	modCache[modPath] = node
 	return node, nil
 }
 
-func getHostedRepositoryByNameWithCache(name string) string {
-	normalized := normalizeGoModuleName(name)
-	if repo := getHostedRepositoryByCache(normalized); repo != "" {
-		return repo
+// goModuleID returns the ID hashed from the repository name, the go.mod path, the module name and the version.
+func goModuleID(repoName, goModPath, name, ver string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s/%s", repoName, goModPath, name, ver)))
+	return hex.EncodeToString(hash[:])
+}
+
+// moduleResolver resolves the hosted repository of the Go module.
+// The resolved mappings are cached in memory and persisted in the storage until the TTL expires,
+// so that repeated runs don't access the network for the same modules.
+type moduleResolver struct {
+	resolver    HostedRepositoryResolver
+	storage     HostedRepositoryStorage
+	ttl         time.Duration
+	concurrency int
+	overrides   map[string]string
+	cache       map[string]*HostedRepositoryMapping
+	cacheMu     sync.RWMutex
+}
+
+// resolveAll resolves the hosted repositories of the modules in a batch after parsing the dependency graph.
+// The modules are resolved concurrently, and the cancellation of the context stops the resolution.
+func (r *moduleResolver) resolveAll(ctx context.Context, mods []*GoModule) error {
+	names := make(map[string]struct{})
+	for _, mod := range mods {
+		names[mod.Name] = struct{}{}
+	}
+	eg, egCtx := errgroup.WithContext(ctx)
+	if r.concurrency > 0 {
+		eg.SetLimit(r.concurrency)
+	}
+	for name := range names {
+		eg.Go(func() error {
+			_, err := r.resolveWithCache(egCtx, name)
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	for _, mod := range mods {
+		if mapping := r.getCache(mod.Name); mapping != nil {
+			mod.HostedRepository = mapping.Repository
+			mod.Subdirectory = mapping.Subdirectory
+		}
 	}
-	ret := getHostedRepositoryByName(normalized)
-	setHostedRepositoryCache(normalized, ret)
-	return ret
+	return nil
 }
 
-func getHostedRepositoryByName(name string) string {
-	if repo, _ := getHostedRepositoryByGoProxy(name); repo != "" {
-		return repo
-	}
-	if repo, _ := getHostedRepositoryByGoPkgIn(name); repo != "" {
-		return repo
+func (r *moduleResolver) resolveWithCache(ctx context.Context, name string) (string, error) {
+	if mapping := r.getCache(name); mapping != nil {
+		return mapping.Repository, nil
 	}
-	if repo, _ := getHostedRepositoryByGoImportMetaTag(name); repo != "" {
-		return repo
+	mapping, err := r.resolveWithStorage(ctx, name, false)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if mapping == nil {
+			return "", err
+		}
+		logger(ctx).WarnContext(ctx, "failed to resolve hosted repository", "module", name, "repository", mapping.Repository, "error", err)
 	}
-	return name
+	r.setCache(name, mapping)
+	return mapping.Repository, nil
 }
 
-var (
-	hostRepoCache   = make(map[string]string)
-	hostRepoCacheMu sync.RWMutex
-)
-
-func getHostedRepositoryByCache(name string) string {
-	hostRepoCacheMu.RLock()
-	defer hostRepoCacheMu.RUnlock()
-	return hostRepoCache[name]
-}
-
-func setHostedRepositoryCache(key, value string) {
-	hostRepoCacheMu.Lock()
-	defer hostRepoCacheMu.Unlock()
-	hostRepoCache[key] = value
-}
-
-func normalizeGoModuleName(name string) string {
-	parts := strings.Split(name, "/")
-	if len(parts) <= 3 {
-		return name
+// resolveWithStorage returns the mapping stored in the storage if it is an override or it is not expired.
+// Otherwise, resolves the hosted repository and stores it. If refresh is true, the stored mapping except override is ignored.
+// If only storing the mapping fails, the resolved mapping is returned with the error.
+// If no strategy resolves the module, the module path is used as the repository and it is never stored,
+// so that it is resolved again by the next run. In that case, the error of the strategies is also returned.
+func (r *moduleResolver) resolveWithStorage(ctx context.Context, name string, refresh bool) (*HostedRepositoryMapping, error) {
+	if repo, exists := r.overrides[name]; exists {
+		return &HostedRepositoryMapping{ModulePath: name, Repository: repo, IsOverride: true}, nil
 	}
-	return strings.Join(parts[:3], "/")
-}
-
-func getHostedRepositoryByGoProxy(name string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://proxy.golang.org/%s/@latest", name))
-	if err != nil {
-		return "", err
+	if r.storage != nil {
+		stored, err := r.storage.FindHostedRepository(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find hosted repository of %s: %w", name, err)
+		}
+		if stored != nil && (stored.IsOverride || (!refresh && time.Since(stored.ResolvedAt) < r.ttl)) {
+			return stored, nil
+		}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed to call proxy.golang.org: %s: %s", resp.Status, string(body))
+	root, err := r.resolver.ResolveHostedRepository(ctx, name)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
-
-	var v struct {
-		Origin struct {
-			URL string
-		}
+	if err != nil || root == nil {
+		return &HostedRepositoryMapping{ModulePath: name, Repository: name, ResolvedAt: time.Now()}, err
+	}
+	mapping := &HostedRepositoryMapping{
+		ModulePath:   name,
+		Repository:   root.Repository,
+		Subdirectory: root.Subdirectory,
+		ResolvedAt:   time.Now(),
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
-		return "", fmt.Errorf("failed to decode %s module: %w", name, err)
+	if r.storage == nil {
+		return mapping, nil
 	}
-	return strings.TrimPrefix(v.Origin.URL, "https://"), nil
+	if err := r.storage.InsertOrUpdateHostedRepository(ctx, mapping); err != nil {
+		return mapping, fmt.Errorf("failed to save hosted repository of %s: %w", name, err)
+	}
+	return mapping, nil
 }
 
-func getHostedRepositoryByGoImportMetaTag(name string) (string, error) {
-	c := colly.NewCollector()
-	var repo string
-	c.OnHTML("meta[name=go-import]", func(e *colly.HTMLElement) {
-		content := e.Attr("content")
-		if content == "" {
-			return
-		}
-		parts := strings.Split(content, " ")
-		if len(parts) != 3 {
-			return
-		}
-		repo = strings.TrimSuffix(parts[2], ".git")
-	})
-	if err := c.Visit(fmt.Sprintf("https://%s?go-get=1", name)); err != nil {
-		return "", err
-	}
-	return strings.TrimPrefix(repo, "https://"), nil
+func (r *moduleResolver) getCache(name string) *HostedRepositoryMapping {
+	r.cacheMu.RLock()
+	defer r.cacheMu.RUnlock()
+	return r.cache[name]
 }
 
-var (
-	gopkgInPat          = regexp.MustCompile(`gopkg.in/(.+)\.v[0-9]+$`)
-	gopkgInWithOwnerPat = regexp.MustCompile(`gopkg.in/(.+)/(.+)\.v[0-9]+$`)
-)
+func (r *moduleResolver) setCache(key string, value *HostedRepositoryMapping) {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	r.cache[key] = value
+}
 
-func getHostedRepositoryByGoPkgIn(name string) (string, error) {
-	{
-		matched := gopkgInWithOwnerPat.FindAllStringSubmatch(name, -1)
-		if len(matched) != 0 {
-			if len(matched[0]) == 3 {
-				owner := matched[0][1]
-				pkg := matched[0][2]
-				return fmt.Sprintf("github.com/%s/%s", owner, pkg), nil
-			}
-		}
-	}
-	{
-		matched := gopkgInPat.FindAllStringSubmatch(name, -1)
-		if len(matched) != 0 {
-			if len(matched[0]) == 2 {
-				pkg := matched[0][1]
-				return fmt.Sprintf("github.com/go-%[1]s/%[1]s", pkg), nil
-			}
-		}
-	}
-	return "", nil
+func (r *moduleResolver) deleteCache(key string) {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	delete(r.cache, key)
 }
 
 func splitModNameAndVersion(mod string) (string, string, error) {
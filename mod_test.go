@@ -1,6 +1,13 @@
 package modrank
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
 
 func TestHostedRepository(t *testing.T) {
 	tests := []struct {
@@ -24,12 +31,117 @@ func TestHostedRepository(t *testing.T) {
 			expected: "github.com/cncf/udpa",
 		},
 	}
+	resolver := &moduleResolver{
+		resolver: newDefaultHostedRepositoryResolver(
+			http.DefaultClient, newGoProxyFromEnv(), defaultHostedRepositoryTimeout, defaultHostedRepositoryConcurrency, nil,
+		),
+		cache: make(map[string]*HostedRepositoryMapping),
+	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got := getHostedRepositoryByNameWithCache(test.name)
+			got, err := resolver.resolveWithCache(context.Background(), test.name)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if test.expected != got {
 				t.Fatalf("failed to get hosted repository name from %s. got %s", test.name, got)
 			}
 		})
 	}
 }
+
+func TestHostedRepositoryStorage(t *testing.T) {
+	ctx := context.Background()
+	var called int
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called++
+		_, _ = w.Write([]byte(`{"Version": "v1.0.0", "Origin": {"VCS": "git", "URL": "https://git.example.com/owner/mod"}}`))
+	}))
+	defer proxy.Close()
+
+	storage, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.CreateHostedRepositoryStorageIfNotExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	newResolver := func(ttl time.Duration) *moduleResolver {
+		return &moduleResolver{
+			resolver: newDefaultHostedRepositoryResolver(
+				http.DefaultClient, &goProxy{entries: parseGoProxy(proxy.URL)}, time.Second, 1, nil,
+			),
+			storage: storage,
+			ttl:     ttl,
+			cache:   make(map[string]*HostedRepositoryMapping),
+		}
+	}
+
+	t.Run("stored", func(t *testing.T) {
+		// the mapping resolved by the previous run is used by the next run.
+		for range 2 {
+			if got, _ := newResolver(time.Hour).resolveWithCache(ctx, "example.com/owner/mod"); got != "git.example.com/owner/mod" {
+				t.Fatalf("unexpected hosted repository: %s", got)
+			}
+		}
+		if called != 1 {
+			t.Fatalf("unexpected number of proxy calls: %d", called)
+		}
+	})
+	t.Run("expired", func(t *testing.T) {
+		called = 0
+		if got, _ := newResolver(0).resolveWithCache(ctx, "example.com/owner/mod"); got != "git.example.com/owner/mod" {
+			t.Fatalf("unexpected hosted repository: %s", got)
+		}
+		if called != 1 {
+			t.Fatalf("failed to resolve expired mapping: %d", called)
+		}
+	})
+	t.Run("override", func(t *testing.T) {
+		called = 0
+		if err := storage.InsertOrUpdateHostedRepository(ctx, &HostedRepositoryMapping{
+			ModulePath: "example.com/owner/mod",
+			Repository: "github.com/owner/mod",
+			IsOverride: true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		mapping, err := newResolver(0).resolveWithStorage(ctx, "example.com/owner/mod", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mapping.Repository != "github.com/owner/mod" || !mapping.IsOverride {
+			t.Fatalf("unexpected mapping: %+v", mapping)
+		}
+		if called != 0 {
+			t.Fatalf("unexpected number of proxy calls: %d", called)
+		}
+	})
+	t.Run("not resolved", func(t *testing.T) {
+		resolver := newResolver(time.Hour)
+		resolver.resolver = StaticHostedRepositoryResolver(nil)
+		if got, _ := resolver.resolveWithCache(ctx, "example.com/owner/unknown"); got != "example.com/owner/unknown" {
+			t.Fatalf("unexpected hosted repository: %s", got)
+		}
+		// the module path used as the repository is resolved again by the next run.
+		stored, err := storage.FindHostedRepository(ctx, "example.com/owner/unknown")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored != nil {
+			t.Fatalf("unexpected stored mapping: %+v", stored)
+		}
+	})
+	t.Run("storage error", func(t *testing.T) {
+		// the table doesn't exist because the storage isn't migrated.
+		broken, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "broken.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resolver := newResolver(time.Hour)
+		resolver.storage = broken
+		if _, err := resolver.resolveWithCache(ctx, "example.com/owner/other"); err == nil {
+			t.Fatal("expected error for failure of storage")
+		}
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,6 +14,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/mod/modfile"
 	"golang.org/x/sync/errgroup"
@@ -21,17 +24,30 @@ import (
 )
 
 type ModRank struct {
-	storage           Storage
-	scoredModCache    map[*GoModule]int
-	logLevel          slog.Level
-	logger            *slog.Logger
-	tmpDir            string
-	gitAccessToken    *GitAccessToken
-	githubAccessToken *GitHubAccessToken
-	githubClient      *GitHubClient
-	githubAPICache    bool
-	cleanupRepo       bool
-	workerNum         int
+	storage               Storage
+	scoredModCache        map[*GoModule]int
+	logLevel              slog.Level
+	logger                *slog.Logger
+	tmpDir                string
+	gitAccessToken        *GitAccessToken
+	githubAccessToken     *GitHubAccessToken
+	githubClient          *GitHubClient
+	githubClientOpts      []GitHubClientOption
+	httpClient            *http.Client
+	goProxy               *goProxy
+	moduleResolver        *moduleResolver
+	hostedRepoTTL         time.Duration
+	hostedRepoResolver    HostedRepositoryResolver
+	hostedRepoStrategies  []*HostedRepositoryStrategy
+	hostedRepoTimeout     time.Duration
+	hostedRepoConcurrency int
+	moduleAliases         *moduleAliases
+	hostedRepoOverrides   map[string]string
+	repoHosts             []RepositoryHost
+	githubAPICache        bool
+	cleanupRepo           bool
+	workerNum             int
+	noAutoMigration       bool
 }
 
 type GitAccessToken struct {
@@ -58,21 +74,50 @@ func GitHubStaticAccessToken(tk string) *GitHubAccessToken {
 	}
 }
 
+// NewGitAccessToken creates the access token issued by the issuer every time it is used.
+// e.g.) NewGitAccessToken(githubAppTokenIssuer.Issue)
+func NewGitAccessToken(issuer TokenIssuer) *GitAccessToken {
+	return &GitAccessToken{issuer: issuer}
+}
+
 const defaultWorkerNum = 1
 
-const gitConfigTmpl = `
-[url "https://x-access-token:%s@github.com/"]
-    insteadOf = https://github.com/
+// defaultHostedRepositoryTTL is the period during which the resolved hosted repository stored in the storage is used.
+const defaultHostedRepositoryTTL = 7 * 24 * time.Hour
+
+const (
+	gitConfigURLTmpl = `
+[url "https://x-access-token:%[1]s@%[2]s/"]
+    insteadOf = https://%[2]s/
+`
+	gitConfigCredentialTmpl = `
 [credential]
     helper = ""
 `
+)
+
+// gitConfig returns the content of the temporary gitconfig to access all GitHub hosts with the token.
+func gitConfig(tk string, hosts []string) string {
+	var b strings.Builder
+	for _, host := range hosts {
+		fmt.Fprintf(&b, gitConfigURLTmpl, tk, host)
+	}
+	b.WriteString(gitConfigCredentialTmpl)
+	return b.String()
+}
 
 func New(ctx context.Context, opts ...Option) (*ModRank, error) {
 	modRank := &ModRank{
-		scoredModCache:    make(map[*GoModule]int),
-		githubAccessToken: GitHubStaticAccessToken(os.Getenv("GITHUB_TOKEN")),
-		workerNum:         defaultWorkerNum,
-		logLevel:          slog.LevelInfo,
+		scoredModCache:        make(map[*GoModule]int),
+		githubAccessToken:     GitHubStaticAccessToken(os.Getenv("GITHUB_TOKEN")),
+		workerNum:             defaultWorkerNum,
+		logLevel:              slog.LevelInfo,
+		goProxy:               newGoProxyFromEnv(),
+		hostedRepoTTL:         defaultHostedRepositoryTTL,
+		hostedRepoTimeout:     defaultHostedRepositoryTimeout,
+		hostedRepoConcurrency: defaultHostedRepositoryConcurrency,
+		hostedRepoOverrides:   make(map[string]string),
+		moduleAliases:         newModuleAliases(),
 	}
 	for _, opt := range opts {
 		if err := opt(modRank); err != nil {
@@ -82,41 +127,107 @@ func New(ctx context.Context, opts ...Option) (*ModRank, error) {
 	if modRank.tmpDir == "" {
 		modRank.tmpDir = helper.TmpRoot
 	}
-	modRank.githubClient = NewGitHubClient(ctx, modRank.githubAccessToken)
+	if modRank.httpClient == nil {
+		modRank.httpClient = http.DefaultClient
+	}
+	modRank.githubClient = NewGitHubClient(ctx, modRank.githubAccessToken, modRank.githubClientOpts...)
+	// hosts registered by WithRepositoryHost take precedence over GitHub.
+	modRank.repoHosts = append(modRank.repoHosts, &githubHost{client: modRank.githubClient})
+	for _, host := range modRank.repoHosts {
+		if setter, ok := host.(defaultHTTPClientSetter); ok {
+			setter.setDefaultHTTPClient(modRank.httpClient)
+		}
+	}
 	if modRank.logger == nil {
 		modRank.logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 			Level: modRank.logLevel,
 		}))
 	}
 	if modRank.storage == nil {
-		tmpFile := "tmp.db"
-		dbFile := filepath.Join(modRank.tmpDir, tmpFile)
-		if err := os.MkdirAll(modRank.tmpDir, 0o755); err != nil {
-			return nil, fmt.Errorf("failed to create temporary directory to create temporary database file: %s", modRank.tmpDir)
-		}
-		if _, err := os.OpenFile(dbFile, os.O_RDWR|os.O_CREATE, 0644); err != nil {
-			return nil, fmt.Errorf("failed to create temporary database file: %s", dbFile)
-		}
-		modRank.logger.Debug("temporary database file", slog.String("path", dbFile))
-		storage, err := NewSQLiteStorage(dbFile)
+		// the data isn't shared with other processes and doesn't remain after the process exits.
+		storage, err := NewMemoryStorage()
 		if err != nil {
 			return nil, err
 		}
 		modRank.storage = storage
 	}
+	if err := modRank.migrateStorage(ctx); err != nil {
+		return nil, err
+	}
+	if modRank.hostedRepoResolver == nil {
+		modRank.hostedRepoResolver = newDefaultHostedRepositoryResolver(
+			modRank.httpClient,
+			modRank.goProxy,
+			modRank.hostedRepoTimeout,
+			modRank.hostedRepoConcurrency,
+			modRank.hostedRepoStrategies,
+		)
+	}
+	// the storage not implementing HostedRepositoryStorage resolves hosted repositories without persisting them.
+	hostedRepoStorage, _ := modRank.storage.(HostedRepositoryStorage)
+	modRank.moduleResolver = &moduleResolver{
+		resolver:    modRank.hostedRepoResolver,
+		storage:     hostedRepoStorage,
+		ttl:         modRank.hostedRepoTTL,
+		concurrency: modRank.hostedRepoConcurrency,
+		overrides:   modRank.hostedRepoOverrides,
+		cache:       make(map[string]*HostedRepositoryMapping),
+	}
 	return modRank, nil
 }
 
+// migrateStorage applies the pending migrations of the storage schema.
+// If the auto migration is disabled, it returns the error instead if there are pending migrations.
+func (r *ModRank) migrateStorage(ctx context.Context) error {
+	s, ok := r.storage.(MigratableStorage)
+	if !ok {
+		return nil
+	}
+	if !r.noAutoMigration {
+		return s.Migrate(ctx)
+	}
+	statuses, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+	for _, st := range statuses {
+		if st.Unknown {
+			return fmt.Errorf("the storage schema version %d is newer than the supported version", st.Version)
+		}
+	}
+	if pending := PendingMigrations(statuses); len(pending) != 0 {
+		return fmt.Errorf("the storage has %d pending migrations. please migrate the storage schema before running", len(pending))
+	}
+	return nil
+}
+
+// Close closes the storage if it implements io.Closer.
+// e.g.) MemoryStorage saves the data to the snapshot file.
+func (r *ModRank) Close() error {
+	if closer, ok := r.storage.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
 type GoModuleScore struct {
 	Name       string `json:"name"`
 	Repository string `json:"repository"`
-	Score      int    `json:"score"`
+	// Subdirectory is the directory containing the module within the repository. e.g.) go
+	Subdirectory string `json:"subdirectory,omitempty"`
+	Score        int    `json:"score"`
+	// Aliases is the scores of the original module names merged into this module by the module aliases.
+	// e.g.) the score of github.com/golang/protobuf merged into google.golang.org/protobuf
+	Aliases []*GoModuleScore `json:"aliases,omitempty"`
+	// LinkedTo is the module name this module is linked to by the module alias without merging the score.
+	LinkedTo string `json:"linkedTo,omitempty"`
 }
 
 // UpdateRepositoryStatusByGitHubAPI if you are working with a large number of repositories and they are all on GitHub,
 // it is useful to skip the process of cloning the repositories by checking in advance
 // whether they have been archived or whether they have a go.mod file, and thus shorten the process.
 // This API checks for these things and saves them in the database.
+// Repositories on other services such as GitLab are also checked if their RepositoryHost is registered by WithRepositoryHost().
 func (r *ModRank) UpdateRepositoryStatusByGitHubAPI(ctx context.Context, repos ...*repository.Repository) error {
 	ctx = withLogger(ctx, r.logger)
 	if err := r.storage.CreateRepositoryStorageIfNotExists(ctx); err != nil {
@@ -129,7 +240,7 @@ func (r *ModRank) UpdateRepositoryStatusByGitHubAPI(ctx context.Context, repos .
 	totalRepoNum := len(repos)
 	updatedRepoNum := int32(0)
 
-	if err := r.githubClient.CreateGitHubRepositoryCache(ctx, repos); err != nil {
+	if err := r.createRepositoryCache(ctx, repos); err != nil {
 		return err
 	}
 
@@ -145,25 +256,133 @@ func (r *ModRank) UpdateRepositoryStatusByGitHubAPI(ctx context.Context, repos .
 				ratio := float64(curNum) / float64(totalRepoNum) * 100
 				logger(workerCtx).DebugContext(workerCtx, fmt.Sprintf("progress: %d/%d (%.1f%%)", curNum, totalRepoNum, ratio))
 			}()
-			return r.updateRepositoryStatusByGitHubAPI(ctx, repo)
+			err := r.updateRepositoryStatusByGitHubAPI(ctx, repo)
+			var repoErr *RepositoryError
+			if errors.As(err, &repoErr) {
+				// the error of the specific repository doesn't stop updating other repositories.
+				logger(workerCtx).WarnContext(workerCtx, "failed to update repository status", "repo", repo.FullName(), "error", err)
+				return nil
+			}
+			return err
 		})
 	}
 	if err := eg.Wait(); err != nil {
 		return err
 	}
+	r.logGitHubRateLimits(ctx)
+	return nil
+}
+
+// GitHubClient returns the client of the GitHub API used by ModRank.
+// Use it to discover repositories (e.g. NewGitHubOwnerSource) so that the requests share the token pool and the rate limit state.
+func (r *ModRank) GitHubClient() *GitHubClient {
+	return r.githubClient
+}
+
+// GitHubRateLimits returns the latest rate limit state of the GitHub API observed while scanning.
+func (r *ModRank) GitHubRateLimits() []*GitHubRateLimit {
+	return r.githubClient.RateLimits()
+}
+
+func (r *ModRank) logGitHubRateLimits(ctx context.Context) {
+	for _, limit := range r.githubClient.RateLimits() {
+		logger(ctx).DebugContext(ctx, "GitHub API rate limit",
+			"token", limit.Token,
+			"resource", limit.Resource,
+			"remaining", limit.Remaining,
+			"limit", limit.Limit,
+			"reset", limit.Reset,
+		)
+	}
+	if cost := r.githubClient.GraphQLCost(); cost != 0 {
+		logger(ctx).DebugContext(ctx, "GitHub GraphQL API cost", "cost", cost)
+	}
+}
+
+// ResolveHostedRepository returns the hosted repository of the Go module path.
+// The mapping stored in the storage is used until the TTL expires. If refresh is true, the mapping is resolved again
+// and stored even if it is not expired. The override mapping is always returned as it is.
+// If the hosted repository can't be resolved, the module path is returned as the repository without storing it,
+// together with the error of the resolution strategies if any.
+func (r *ModRank) ResolveHostedRepository(ctx context.Context, modulePath string, refresh bool) (*HostedRepositoryMapping, error) {
+	ctx = withLogger(ctx, r.logger)
+	if err := r.createHostedRepositoryStorageIfNotExists(ctx); err != nil {
+		return nil, err
+	}
+	r.moduleResolver.deleteCache(modulePath)
+	return r.moduleResolver.resolveWithStorage(ctx, modulePath, refresh)
+}
+
+// HostedRepositories returns all mappings of the hosted repository stored in the storage.
+// If the storage doesn't implement HostedRepositoryStorage, returns ErrHostedRepositoryStorageNotSupported.
+func (r *ModRank) HostedRepositories(ctx context.Context) ([]*HostedRepositoryMapping, error) {
+	s, err := r.hostedRepositoryStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.FindHostedRepositories(ctx)
+}
+
+// SetHostedRepository stores the hosted repository of the Go module path as the override mapping.
+// The override mapping never expires and is used until it is deleted by DeleteHostedRepository.
+// If the storage doesn't implement HostedRepositoryStorage, returns ErrHostedRepositoryStorageNotSupported.
+func (r *ModRank) SetHostedRepository(ctx context.Context, modulePath, repo string) error {
+	s, err := r.hostedRepositoryStorage(ctx)
+	if err != nil {
+		return err
+	}
+	r.moduleResolver.deleteCache(modulePath)
+	return s.InsertOrUpdateHostedRepository(ctx, &HostedRepositoryMapping{
+		ModulePath: modulePath,
+		Repository: repo,
+		ResolvedAt: time.Now(),
+		IsOverride: true,
+	})
+}
+
+// DeleteHostedRepository deletes the mapping of the Go module path from the storage.
+// The hosted repository is resolved again on the next access.
+// If the storage doesn't implement HostedRepositoryStorage, returns ErrHostedRepositoryStorageNotSupported.
+func (r *ModRank) DeleteHostedRepository(ctx context.Context, modulePath string) error {
+	s, err := r.hostedRepositoryStorage(ctx)
+	if err != nil {
+		return err
+	}
+	r.moduleResolver.deleteCache(modulePath)
+	return s.DeleteHostedRepository(ctx, modulePath)
+}
+
+// hostedRepositoryStorage returns the storage of the hosted repositories after creating it if not exists.
+func (r *ModRank) hostedRepositoryStorage(ctx context.Context) (HostedRepositoryStorage, error) {
+	s, ok := r.storage.(HostedRepositoryStorage)
+	if !ok {
+		return nil, ErrHostedRepositoryStorageNotSupported
+	}
+	if err := s.CreateHostedRepositoryStorageIfNotExists(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// createHostedRepositoryStorageIfNotExists creates the storage of the hosted repositories only if the storage supports it.
+func (r *ModRank) createHostedRepositoryStorageIfNotExists(ctx context.Context) error {
+	if _, err := r.hostedRepositoryStorage(ctx); err != nil && !errors.Is(err, ErrHostedRepositoryStorageNotSupported) {
+		return err
+	}
 	return nil
 }
 
 func (r *ModRank) updateRepositoryStatusByGitHubAPI(ctx context.Context, repo *repository.Repository) error {
-	if !repo.IsGitHubRepository() {
+	host := r.findRepositoryHost(repo)
+	if host == nil {
 		return nil
 	}
-	repoStat, _ := r.storage.FindRepositoryByName(ctx, repo.NameWithOwner())
+	repoStat, _ := r.storage.FindRepositoryByName(ctx, repo.FullName())
 	if repoStat != nil && repoStat.IsArchived {
 		logger(ctx).DebugContext(ctx, "skip updating: repository is already archived")
 		return nil
 	}
-	if repoStat != nil && repoStat.ExistsGoMod {
+	if repoStat != nil && repoStat.ExistsGoMod && !repoStat.NotFound {
 		logger(ctx).DebugContext(ctx, "skip updating: repository has go.mod")
 		return nil
 	}
@@ -173,45 +392,81 @@ func (r *ModRank) updateRepositoryStatusByGitHubAPI(ctx context.Context, repo *r
 		lastHead = repoStat.HeadCommitHash
 	}
 
-	head, err := r.githubClient.GetHeadCommit(ctx, repo.Owner(), repo.Name())
+	head, err := host.GetHeadCommit(ctx, repo)
+	if errors.Is(err, ErrRepositoryNotFound) {
+		logger(ctx).DebugContext(ctx, "save repository status", "notFound", true)
+		return r.storage.InsertOrUpdateRepository(ctx, notFoundRepositoryStatus(repo, repoStat))
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get head commit: %w", err)
 	}
+	if head != "" && lastHead == head && repoStat.NotFound {
+		// the repository is restored with the same head commit.
+		return r.storage.InsertOrUpdateRepository(ctx, &RepositoryStatus{
+			NameWithOwner:  repo.FullName(),
+			HeadCommitHash: head,
+			ExistsGoMod:    repoStat.ExistsGoMod,
+			CanonicalName:  canonicalName(host, repo),
+		})
+	}
 	if head != "" && lastHead == head {
 		logger(ctx).DebugContext(ctx, "skip updating: HEAD commit is already scanned")
 		return nil
 	}
 
-	isArchived, err := r.githubClient.IsArchived(ctx, repo.Owner(), repo.Name())
+	isArchived, err := host.IsArchived(ctx, repo)
 	if err != nil {
 		return fmt.Errorf("failed to get archived status: %w", err)
 	}
 	if isArchived {
 		logger(ctx).DebugContext(ctx, "save repository status", "isArchived", true)
 		if err := r.storage.InsertOrUpdateRepository(ctx, &RepositoryStatus{
-			NameWithOwner:  repo.NameWithOwner(),
+			NameWithOwner:  repo.FullName(),
 			IsArchived:     true,
 			HeadCommitHash: head,
+			CanonicalName:  canonicalName(host, repo),
 		}); err != nil {
 			return err
 		}
 		return nil
 	}
-	existsGoMod, err := r.githubClient.ExistsGoMod(ctx, repo.Owner(), repo.Name())
+	existsGoMod, err := host.ExistsGoMod(ctx, repo)
 	if err != nil {
 		return fmt.Errorf("failed to find go.mod with unexpected error: %w", err)
 	}
 	logger(ctx).DebugContext(ctx, "save repository status", "go.mod", existsGoMod)
 	if err := r.storage.InsertOrUpdateRepository(ctx, &RepositoryStatus{
-		NameWithOwner:  repo.NameWithOwner(),
+		NameWithOwner:  repo.FullName(),
 		ExistsGoMod:    existsGoMod,
 		HeadCommitHash: lastHead, // keep last head value to update scanning process.
+		CanonicalName:  canonicalName(host, repo),
 	}); err != nil {
 		return err
 	}
 	return nil
 }
 
+// notFoundRepositoryStatus returns the status of the repository that is not found.
+// The last status is kept to be restored when the repository can be accessed again.
+func notFoundRepositoryStatus(repo *repository.Repository, last *RepositoryStatus) *RepositoryStatus {
+	st := &RepositoryStatus{NameWithOwner: repo.FullName()}
+	if last != nil {
+		v := *last
+		st = &v
+	}
+	st.NotFound = true
+	return st
+}
+
+// canonicalName returns the current name of the renamed repository if the host can report it.
+func canonicalName(host RepositoryHost, repo *repository.Repository) string {
+	reporter, ok := host.(canonicalNameReporter)
+	if !ok {
+		return ""
+	}
+	return reporter.CanonicalName(repo)
+}
+
 // Run compute and return the Go module score for each specified repository.
 // If UpdateRepositoryStatusByGitHubAPI has been called previously, precomputed statuses can be used to reduce processing time.
 func (r *ModRank) Run(ctx context.Context, repos ...*repository.Repository) ([]*GoModuleScore, error) {
@@ -222,6 +477,9 @@ func (r *ModRank) Run(ctx context.Context, repos ...*repository.Repository) ([]*
 	if err := r.storage.CreateGoModuleStorageIfNotExists(ctx); err != nil {
 		return nil, err
 	}
+	if err := r.createHostedRepositoryStorageIfNotExists(ctx); err != nil {
+		return nil, err
+	}
 
 	eg, workerCtx := errgroup.WithContext(ctx)
 	eg.SetLimit(r.workerNum)
@@ -230,7 +488,7 @@ func (r *ModRank) Run(ctx context.Context, repos ...*repository.Repository) ([]*
 	scannedRepoNum := int32(0)
 
 	if r.githubAPICache {
-		if err := r.githubClient.CreateGitHubRepositoryCache(ctx, repos); err != nil {
+		if err := r.createRepositoryCache(ctx, repos); err != nil {
 			return nil, err
 		}
 	}
@@ -262,6 +520,11 @@ func (r *ModRank) Run(ctx context.Context, repos ...*repository.Repository) ([]*
 	if err := eg.Wait(); err != nil {
 		return nil, err
 	}
+	if err := ctx.Err(); err != nil {
+		// the scan of each repository is stopped by the cancellation without returning error.
+		return nil, err
+	}
+	r.logGitHubRateLimits(ctx)
 	return r.Score(ctx, repos...)
 }
 
@@ -272,7 +535,7 @@ func (r *ModRank) Score(ctx context.Context, repos ...*repository.Repository) ([
 	ctx = withLogger(ctx, r.logger)
 	repoMap := make(map[string]*repository.Repository)
 	for _, repo := range repos {
-		repoMap[repo.NameWithOwner()] = repo
+		repoMap[repo.FullName()] = repo
 	}
 	roots, err := r.storage.FindRootGoModules(ctx)
 	if err != nil {
@@ -294,8 +557,9 @@ func (r *ModRank) Score(ctx context.Context, repos ...*repository.Repository) ([
 	for mod, score := range r.scoredModCache {
 		if _, exists := modToScore[mod.Name]; !exists {
 			modToScore[mod.Name] = &GoModuleScore{
-				Name:       mod.Name,
-				Repository: mod.HostedRepository,
+				Name:         mod.Name,
+				Repository:   mod.HostedRepository,
+				Subdirectory: mod.Subdirectory,
 			}
 		}
 		modToScore[mod.Name].Score += score
@@ -304,6 +568,7 @@ func (r *ModRank) Score(ctx context.Context, repos ...*repository.Repository) ([
 	for _, mod := range modToScore {
 		results = append(results, mod)
 	}
+	results = r.moduleAliases.merge(results)
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].Score > results[j].Score
 	})
@@ -325,48 +590,69 @@ func (r *ModRank) scoreGoModule(ctx context.Context, mod *GoModule, weight int,
 }
 
 func (r *ModRank) scanRepo(ctx context.Context, repo *repository.Repository) error {
-	repoStat, _ := r.storage.FindRepositoryByName(ctx, repo.NameWithOwner())
+	repoStat, _ := r.storage.FindRepositoryByName(ctx, repo.FullName())
 	if repoStat != nil && repoStat.IsArchived {
 		logger(ctx).DebugContext(ctx, "skip scanning: repository is already archived", "from", "db")
 		return nil
 	}
-	if repoStat != nil && !repoStat.ExistsGoMod {
+	// If the API cache is enabled, the repository that was not found is checked again by the API.
+	if repoStat != nil && repoStat.NotFound && !r.githubAPICache {
+		logger(ctx).DebugContext(ctx, "skip scanning: repository is not found", "from", "db")
+		return nil
+	}
+	if repoStat != nil && !repoStat.NotFound && !repoStat.ExistsGoMod {
 		// UpdateRepositoryStatusByGitHubAPI in advance to allow for the possibility of go.mod being added later.
 		logger(ctx).DebugContext(ctx, "skip scanning: repository doesn't have go.mod", "from", "db")
 		return nil
 	}
 
+	if err := repo.MigrateLegacyClonePath(); err != nil {
+		logger(ctx).WarnContext(ctx, "failed to migrate cloned repository", "error", err)
+	}
+
 	path := repo.Path()
 	// If a repository has already been cloned locally and its head commit is stored in the database,
 	// it is assumed to have been scanned with that head commit and skipped.
-	if head, _ := repo.HeadCommit(ctx, path); head != "" && (repoStat != nil && repoStat.HeadCommitHash == head) {
+	// The repository that was not found is scanned again to clear its status even if the head commit is the same.
+	if head, _ := repo.HeadCommit(ctx, path); head != "" && (repoStat != nil && !repoStat.NotFound && repoStat.HeadCommitHash == head) {
 		logger(ctx).DebugContext(ctx, "skip scanning: HEAD commit is already scanned", "from", "cloned_repo")
 		return nil
 	}
 
-	if r.githubAPICache && repo.IsGitHubRepository() {
-		head, err := r.githubClient.GetHeadCommit(ctx, repo.Owner(), repo.Name())
+	// Local and mirror repositories may differ from the remote default branch,
+	// so the head commit from the API is not used for them.
+	if host := r.findRepositoryHost(repo); r.githubAPICache && host != nil && !repo.IsLocal() && !repo.IsMirror() {
+		head, err := host.GetHeadCommit(ctx, repo)
+		if errors.Is(err, ErrRepositoryNotFound) {
+			logger(ctx).DebugContext(ctx, "skip scanning: repository is not found", "from", "hosting_api")
+			return r.storage.InsertOrUpdateRepository(ctx, notFoundRepositoryStatus(repo, repoStat))
+		}
 		if err != nil {
 			return fmt.Errorf("failed to get head commit: %w", err)
 		}
-		if head != "" && (repoStat != nil && repoStat.HeadCommitHash == head) {
-			logger(ctx).DebugContext(ctx, "skip scanning: HEAD commit is already scanned", "from", "github_api")
+		if head != "" && (repoStat != nil && !repoStat.NotFound && repoStat.HeadCommitHash == head) {
+			logger(ctx).DebugContext(ctx, "skip scanning: HEAD commit is already scanned", "from", "hosting_api")
 			return nil
 		}
 	}
 
-	logger(ctx).DebugContext(ctx, "cloning repository...")
-	if err := os.MkdirAll(path, 0o755); err != nil {
-		return err
-	}
-	if err := repo.Clone(ctx, path); err != nil {
-		if err == repository.ErrEmptyRemoteRepository {
-			return nil
+	if repo.IsLocal() {
+		logger(ctx).DebugContext(ctx, "skip cloning: local repository")
+	} else {
+		logger(ctx).DebugContext(ctx, "cloning repository...")
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return err
+		}
+		if err := repo.Clone(ctx, path); err != nil {
+			if err == repository.ErrEmptyRemoteRepository {
+				return nil
+			}
+			return fmt.Errorf("failed to clone repository: %w", err)
 		}
-		return fmt.Errorf("failed to clone repository: %w", err)
 	}
 
-	if r.cleanupRepo {
+	// Local repositories are not owned by this library, so they are never removed.
+	if r.cleanupRepo && !repo.IsLocal() {
 		defer func() {
 			logger(ctx).DebugContext(ctx, "removing repository...")
 			if err := os.RemoveAll(path); err != nil {
@@ -413,15 +699,19 @@ func (r *ModRank) scanRepo(ctx context.Context, repo *repository.Repository) err
 	if err := eg.Wait(); err != nil {
 		return err
 	}
-	if err := r.storage.InsertOrUpdateGoModules(ctx, repo.NameWithOwner(), goMods); err != nil {
+	if err := r.storage.InsertOrUpdateGoModules(ctx, repo.FullName(), goMods); err != nil {
 		return err
 	}
 	logger(ctx).DebugContext(ctx, "save scanning status", "head", head)
-	if err := r.storage.InsertOrUpdateRepository(ctx, &RepositoryStatus{
-		NameWithOwner:  repo.NameWithOwner(),
+	st := &RepositoryStatus{
+		NameWithOwner:  repo.FullName(),
 		HeadCommitHash: head,
 		ExistsGoMod:    len(paths) != 0,
-	}); err != nil {
+	}
+	if repoStat != nil {
+		st.CanonicalName = repoStat.CanonicalName
+	}
+	if err := r.storage.InsertOrUpdateRepository(ctx, st); err != nil {
 		return err
 	}
 	return nil
@@ -442,7 +732,7 @@ func (r *ModRank) runGoModGraph(ctx context.Context, path string) (string, error
 			if err := os.MkdirAll(r.tmpDir, 0o755); err != nil {
 				return "", fmt.Errorf("failed to create temporary directory to create temporary gitconfig file: %s", r.tmpDir)
 			}
-			if err := os.WriteFile(gitConfigPath, []byte(fmt.Sprintf(gitConfigTmpl, tk)), 0o644); err != nil {
+			if err := os.WriteFile(gitConfigPath, []byte(gitConfig(tk, r.githubClient.Hosts())), 0o644); err != nil {
 				return "", err
 			}
 			logger(ctx).DebugContext(ctx, "update temporary gitconfig", "path", gitConfigPath)
@@ -510,5 +800,8 @@ func (r *ModRank) scanGoModule(ctx context.Context, repo *repository.Repository,
 		mod.setupReference()
 		mods = append(mods, mod)
 	}
+	if err := r.moduleResolver.resolveAll(ctx, mods); err != nil {
+		return nil, err
+	}
 	return mods, nil
 }
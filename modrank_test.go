@@ -3,6 +3,7 @@ package modrank_test
 import (
 	"context"
 	"log/slog"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -51,7 +52,7 @@ func TestModRank_Run(t *testing.T) {
 		t.Fatal(err)
 	}
 	repo, err := repository.New(
-		"https://owner/foo.git",
+		"https://example.com/owner/foo.git",
 		repository.WithClonePath("testdata"),
 		repository.WithCloner(&TestCloner{
 			headCommit: func(_ context.Context, _ string) (string, error) {
@@ -73,3 +74,70 @@ func TestModRank_Run(t *testing.T) {
 		t.Logf("- [%d] %s (%s): %d\n", idx+1, mod.Name, mod.Repository, mod.Score)
 	}
 }
+
+func TestModRank_RunLocalRepository(t *testing.T) {
+	ctx := context.Background()
+	r, err := modrank.New(ctx,
+		modrank.WithSQLiteDSN(filepath.Join(t.TempDir(), "test.db")),
+		modrank.WithLogLevel(slog.LevelDebug),
+		modrank.WithCleanupRepository(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join("testdata", "example.com", "owner", "foo")
+	repo, err := repository.NewLocal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mods, err := r.Run(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mods) == 0 {
+		t.Fatal("failed to scan local repository")
+	}
+	if _, err := os.Stat(filepath.Join(path, "go.mod")); err != nil {
+		t.Fatalf("local repository must not be removed: %v", err)
+	}
+}
+
+func TestModRank_RunSubdirectory(t *testing.T) {
+	ctx := context.Background()
+	r, err := modrank.New(ctx,
+		modrank.WithSQLiteDSN(filepath.Join(t.TempDir(), "test.db")),
+		modrank.WithHostedRepositoryResolver(modrank.HostedRepositoryResolverFunc(
+			func(_ context.Context, modulePath string) (*modrank.HostedRepositoryRoot, error) {
+				if modulePath != "github.com/shurcooL/githubv4" {
+					return nil, nil
+				}
+				return &modrank.HostedRepositoryRoot{
+					Prefix:       modulePath,
+					Repository:   "github.com/shurcooL/monorepo",
+					Subdirectory: "githubv4",
+				}, nil
+			},
+		)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := repository.NewLocal(filepath.Join("testdata", "example.com", "owner", "foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mods, err := r.Run(ctx, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mod := range mods {
+		if mod.Name != "github.com/shurcooL/githubv4" {
+			continue
+		}
+		if mod.Repository != "github.com/shurcooL/monorepo" || mod.Subdirectory != "githubv4" {
+			t.Fatalf("unexpected score: %+v", mod)
+		}
+		return
+	}
+	t.Fatal("failed to find the score of the module in the subdirectory")
+}
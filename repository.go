@@ -1,8 +1,15 @@
 package modrank
 
 type RepositoryStatus struct {
+	// NameWithOwner is the repository name including the host name. e.g.) github.com/goccy/go-modrank
 	NameWithOwner  string
 	HeadCommitHash string
 	IsArchived     bool
 	ExistsGoMod    bool
+	// NotFound is whether the repository is deleted or can't be accessed by the token.
+	// It is updated by UpdateRepositoryStatusByGitHubAPI, and the repository is not scanned while it is true.
+	NotFound bool
+	// CanonicalName is the current name of the renamed or transferred repository reported by the hosting service.
+	// It is empty if the repository isn't renamed. e.g.) github.com/goccy/go-modrank
+	CanonicalName string
 }
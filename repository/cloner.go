@@ -2,11 +2,24 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 )
 
-type DefaultCloner struct{}
+// DefaultCloner is a Cloner implementation using go-git.
+// For SSH urls, the private key specified by SSHKeyPath is used if present, otherwise ssh-agent is used.
+type DefaultCloner struct {
+	// SSHKeyPath is the path to the private key to clone repositories by SSH.
+	SSHKeyPath string
+	// SSHKeyPassphrase is the passphrase for the private key specified by SSHKeyPath.
+	SSHKeyPassphrase string
+	// CABundle is the PEM encoded CA certificates to verify the HTTPS servers in addition to the system roots.
+	CABundle []byte
+	// ProxyURL is the URL of the proxy to clone repositories by HTTPS. e.g.) http://proxy.example.com:8080
+	// If not specified, HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.
+	ProxyURL string
+}
 
 func (c *DefaultCloner) HeadCommit(_ context.Context, path string) (string, error) {
 	if _, err := os.Stat(path); err != nil {
@@ -24,16 +37,38 @@ func (c *DefaultCloner) HeadCommit(_ context.Context, path string) (string, erro
 	return head.Hash().String(), nil
 }
 
-func (c *DefaultCloner) Clone(ctx context.Context, path, url string, auth *BasicAuth) error {
+// Clone clones the repository to the specified path.
+// If the repository has already been cloned to the path, it fetches the latest default branch instead
+// and falls back to a fresh clone only if the local copy cannot be reused.
+func (c *DefaultCloner) Clone(ctx context.Context, path, url string, basicAuth *BasicAuth) error {
+	auth, err := authMethod(url, basicAuth, c.SSHKeyPath, c.SSHKeyPassphrase)
+	if err != nil {
+		return err
+	}
+	if repo, err := plainOpen(path); err == nil {
+		err := syncDefaultBranch(ctx, repo, url, auth, c.CABundle, c.proxyOptions())
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, errBrokenRepository) {
+			return err
+		}
+	}
 	if err := os.RemoveAll(path); err != nil {
 		return err
 	}
 	if _, err := plainCloneContext(ctx, path, false, &CloneOptions{
-		URL:   url,
-		Auth:  auth,
-		Depth: 1,
+		URL:          url,
+		Auth:         auth,
+		Depth:        1,
+		CABundle:     c.CABundle,
+		ProxyOptions: c.proxyOptions(),
 	}); err != nil {
 		return err
 	}
 	return nil
 }
+
+func (c *DefaultCloner) proxyOptions() ProxyOptions {
+	return ProxyOptions{URL: c.ProxyURL}
+}
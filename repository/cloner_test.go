@@ -0,0 +1,218 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goccy/go-modrank/repository"
+)
+
+func TestCloner(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command is not installed")
+	}
+	tests := []struct {
+		name   string
+		cloner repository.Cloner
+	}{
+		{name: "default", cloner: new(repository.DefaultCloner)},
+		{name: "git command", cloner: new(repository.GitCommandCloner)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			root := t.TempDir()
+			src := filepath.Join(root, "src")
+			dst := filepath.Join(root, "dst")
+			git(t, root, "init", "--quiet", "--initial-branch", "main", src)
+			git(t, src, "commit", "--quiet", "--allow-empty", "--message", "first")
+
+			if err := test.cloner.Clone(ctx, dst, src, nil); err != nil {
+				t.Fatal(err)
+			}
+			assertHeadCommit(t, test.cloner, dst, git(t, src, "rev-parse", "HEAD"))
+
+			// reuse the cloned repository.
+			git(t, src, "commit", "--quiet", "--allow-empty", "--message", "second")
+			if err := test.cloner.Clone(ctx, dst, src, nil); err != nil {
+				t.Fatal(err)
+			}
+			assertHeadCommit(t, test.cloner, dst, git(t, src, "rev-parse", "HEAD"))
+
+			// clone again if the local copy is broken.
+			if err := os.WriteFile(filepath.Join(dst, ".git", "HEAD"), []byte("broken"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := test.cloner.Clone(ctx, dst, src, nil); err != nil {
+				t.Fatal(err)
+			}
+			assertHeadCommit(t, test.cloner, dst, git(t, src, "rev-parse", "HEAD"))
+		})
+	}
+}
+
+func TestDefaultClonerBrokenLocalStore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command is not installed")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	git(t, root, "init", "--quiet", "--initial-branch", "main", src)
+	git(t, src, "commit", "--quiet", "--allow-empty", "--message", "first")
+
+	cloner := new(repository.DefaultCloner)
+	if err := cloner.Clone(ctx, dst, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	// the reference of the remote branch can't be updated by the fetch.
+	ref := filepath.Join(dst, ".git", "refs", "remotes", "origin", "main")
+	if err := os.Remove(ref); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(ref, "broken"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git(t, src, "commit", "--quiet", "--allow-empty", "--message", "second")
+	if err := cloner.Clone(ctx, dst, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	assertHeadCommit(t, cloner, dst, git(t, src, "rev-parse", "HEAD"))
+
+	// the local copy is kept if the remote can't be accessed.
+	if err := os.RemoveAll(src); err != nil {
+		t.Fatal(err)
+	}
+	if err := cloner.Clone(ctx, dst, src, nil); err == nil {
+		t.Fatal("expected error for removed remote")
+	}
+	if _, err := os.Stat(filepath.Join(dst, ".git")); err != nil {
+		t.Fatalf("the local copy is removed by the transport error: %v", err)
+	}
+}
+
+func TestGitCommandClonerInsteadOf(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command is not installed")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	git(t, root, "init", "--quiet", "--initial-branch", "main", src)
+	git(t, src, "commit", "--quiet", "--allow-empty", "--message", "first")
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "url."+src+".insteadOf")
+	t.Setenv("GIT_CONFIG_VALUE_0", "modrank-test:")
+
+	cloner := new(repository.GitCommandCloner)
+	if err := cloner.Clone(ctx, dst, "modrank-test:", nil); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(dst, ".git", "modrank-marker")
+	if err := os.WriteFile(marker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, src, "commit", "--quiet", "--allow-empty", "--message", "second")
+	if err := cloner.Clone(ctx, dst, "modrank-test:", nil); err != nil {
+		t.Fatal(err)
+	}
+	assertHeadCommit(t, cloner, dst, git(t, src, "rev-parse", "HEAD"))
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatal("the repository rewritten by insteadOf is cloned again instead of reused")
+	}
+}
+
+func TestClonerCABundle(t *testing.T) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git command is not installed")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	git(t, root, "init", "--quiet", "--initial-branch", "main", src)
+	git(t, src, "commit", "--quiet", "--allow-empty", "--message", "first")
+
+	var userAgent atomic.Value
+	backend := &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		userAgent.Store(req.UserAgent())
+		backend.ServeHTTP(w, req)
+	}))
+	defer server.Close()
+
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	caInfo := filepath.Join(root, "ca.pem")
+	if err := os.WriteFile(caInfo, caBundle, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	url := server.URL + "/src/.git"
+	head := git(t, src, "rev-parse", "HEAD")
+
+	t.Run("default", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "dst")
+		if err := new(repository.DefaultCloner).Clone(ctx, dst, url, nil); err == nil {
+			t.Fatal("expected error for unknown certificate authority")
+		}
+		cloner := &repository.DefaultCloner{CABundle: caBundle}
+		for range 2 {
+			// the second call fetches to the cloned repository.
+			if err := cloner.Clone(ctx, dst, url, nil); err != nil {
+				t.Fatal(err)
+			}
+			assertHeadCommit(t, cloner, dst, head)
+		}
+	})
+	t.Run("git command", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "dst")
+		cloner := &repository.GitCommandCloner{CAInfo: caInfo, UserAgent: "modrank-test"}
+		for range 2 {
+			if err := cloner.Clone(ctx, dst, url, nil); err != nil {
+				t.Fatal(err)
+			}
+			assertHeadCommit(t, cloner, dst, head)
+		}
+		if got := userAgent.Load(); got != "modrank-test" {
+			t.Fatalf("unexpected user agent: %v", got)
+		}
+	})
+}
+
+func assertHeadCommit(t *testing.T, cloner repository.Cloner, path, expected string) {
+	t.Helper()
+
+	got, err := cloner.HeadCommit(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != expected {
+		t.Fatalf("unexpected head commit: expected %s but got %s", expected, got)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-c", "user.name=modrank", "-c", "user.email=modrank@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to run git %s: %v: %s", args[0], err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// GitCommandCloner is a Cloner implementation using the git command installed on the system.
+// Unlike DefaultCloner, it respects the user's git configuration
+// such as credential helpers, SSH config, url.<base>.insteadOf rules and proxy settings.
+type GitCommandCloner struct {
+	// GitPath is the path to the git binary. Default is "git".
+	GitPath string
+	// SSHKeyPath is the path to the private key to clone repositories by SSH.
+	// If not specified, the user's SSH config and ssh-agent are used.
+	// The key must not be protected by the passphrase because the git command cannot be prompted.
+	SSHKeyPath string
+	// CAInfo is the path to the PEM encoded CA certificates file passed by GIT_SSL_CAINFO.
+	// Note that the file replaces the default CA certificates of git.
+	CAInfo string
+	// ProxyURL is the URL of the proxy passed as http.proxy. e.g.) http://proxy.example.com:8080
+	// If not specified, the user's git configuration and the proxy environment variables are used.
+	ProxyURL string
+	// UserAgent is the User-Agent header passed by GIT_HTTP_USER_AGENT.
+	UserAgent string
+}
+
+func (c *GitCommandCloner) HeadCommit(ctx context.Context, path string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+	out, err := c.run(ctx, path, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get head commit from %s: %w", path, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Clone clones the repository to the specified path by `git clone --depth 1`.
+// If the repository has already been cloned to the path, it fetches the latest default branch instead
+// and falls back to a fresh clone only if the local copy cannot be reused.
+func (c *GitCommandCloner) Clone(ctx context.Context, path, url string, auth *BasicAuth) error {
+	if c.isClonedFrom(ctx, path, url) {
+		if _, err := c.run(ctx, path, auth, "fetch", "--depth", "1", "--no-tags", "origin", "HEAD"); err != nil {
+			if strings.Contains(err.Error(), "couldn't find remote ref HEAD") {
+				return ErrEmptyRemoteRepository
+			}
+			return err
+		}
+		if _, err := c.run(ctx, path, nil, "reset", "--hard", "--quiet", "FETCH_HEAD"); err == nil {
+			if _, err := c.run(ctx, path, nil, "clean", "-ffdxq"); err == nil {
+				return nil
+			}
+		}
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	if _, err := c.run(ctx, "", auth, "clone", "--depth", "1", "--quiet", url, path); err != nil {
+		return err
+	}
+	if _, err := c.run(ctx, path, nil, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return ErrEmptyRemoteRepository
+	}
+	return nil
+}
+
+func (c *GitCommandCloner) isClonedFrom(ctx context.Context, path, url string) bool {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		return false
+	}
+	// `git remote get-url` prints the url rewritten by url.<base>.insteadOf rules, so read the configured url instead.
+	out, err := c.run(ctx, path, nil, "config", "--get", "remote.origin.url")
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(out) == url
+}
+
+func (c *GitCommandCloner) run(ctx context.Context, dir string, auth *BasicAuth, args ...string) (string, error) {
+	subcmd := args[0]
+	gitPath := c.GitPath
+	if gitPath == "" {
+		gitPath = "git"
+	}
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if c.SSHKeyPath != "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -o IdentitiesOnly=yes -o BatchMode=yes -i "+shellQuote(c.SSHKeyPath))
+	}
+	// the environment variables take precedence over the user's git configuration.
+	if c.CAInfo != "" {
+		env = append(env, "GIT_SSL_CAINFO="+c.CAInfo)
+	}
+	if c.UserAgent != "" {
+		env = append(env, "GIT_HTTP_USER_AGENT="+c.UserAgent)
+	}
+	if c.ProxyURL != "" {
+		env = appendGitConfigEnv(env, "http.proxy", c.ProxyURL)
+	}
+	if auth != nil {
+		// pass credentials by http header to avoid leaving them in the remote url of .git/config.
+		// The header is passed by the environment variables instead of `-c` so that it isn't visible from the process list.
+		cred := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		env = appendGitConfigEnv(env, "http.extraHeader", "Authorization: Basic "+cred)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, gitPath, args...)
+	cmd.Env = env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to run git %s: %w: %s", subcmd, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// appendGitConfigEnv appends the git configuration passed by GIT_CONFIG_COUNT, GIT_CONFIG_KEY_<n> and GIT_CONFIG_VALUE_<n>.
+// The configurations already specified by the environment are kept.
+func appendGitConfigEnv(env []string, key, value string) []string {
+	var count int
+	for i, kv := range env {
+		if v, found := strings.CutPrefix(kv, "GIT_CONFIG_COUNT="); found {
+			count, _ = strconv.Atoi(v)
+			env = append(env[:i:i], env[i+1:]...)
+			break
+		}
+	}
+	return append(env,
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", count, key),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, value),
+		fmt.Sprintf("GIT_CONFIG_COUNT=%d", count+1),
+	)
+}
+
+// shellQuote quotes the value by single quotes to pass it to the command interpreted by the shell such as GIT_SSH_COMMAND.
+func shellQuote(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
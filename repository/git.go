@@ -1,18 +1,283 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 )
 
 var (
 	plainOpen                = git.PlainOpen
+	plainOpenWithOptions     = git.PlainOpenWithOptions
 	plainCloneContext        = git.PlainCloneContext
+	defaultRemoteName        = git.DefaultRemoteName
 	ErrEmptyRemoteRepository = transport.ErrEmptyRemoteRepository
+
+	// errBrokenRepository is returned when the local copy of the repository cannot be reused.
+	errBrokenRepository = errors.New("local repository is broken")
 )
 
 type (
 	BasicAuth    = http.BasicAuth
 	CloneOptions = git.CloneOptions
+	ProxyOptions = transport.ProxyOptions
 )
+
+// syncDefaultBranch fetches the default branch of the remote repository at depth 1
+// and resets the worktree of the already cloned repository to it.
+// If the local copy cannot be reused, an error wrapping errBrokenRepository is returned.
+func syncDefaultBranch(ctx context.Context, repo *git.Repository, url string, auth transport.AuthMethod, caBundle []byte, proxy ProxyOptions) error {
+	remote, err := repo.Remote(defaultRemoteName)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errBrokenRepository, err)
+	}
+	if urls := remote.Config().URLs; len(urls) == 0 || urls[0] != url {
+		return fmt.Errorf("%w: remote url is not %s", errBrokenRepository, url)
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{
+		Auth:         auth,
+		CABundle:     caBundle,
+		ProxyOptions: proxy,
+	})
+	if err != nil {
+		return wrapLocalObjectError(err)
+	}
+	branch, err := defaultBranchName(refs)
+	if err != nil {
+		return err
+	}
+	remoteBranch := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch.Short())
+	if err := repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("+%s:%s", branch, remoteBranch)),
+		},
+		Depth:        1,
+		Auth:         auth,
+		CABundle:     caBundle,
+		ProxyOptions: proxy,
+		Tags:         git.NoTags,
+		Force:        true,
+	}); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return wrapLocalObjectError(err)
+	}
+	ref, err := repo.Reference(remoteBranch, true)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errBrokenRepository, err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("%w: %w", errBrokenRepository, err)
+	}
+	if err := worktree.Reset(&git.ResetOptions{
+		Commit: ref.Hash(),
+		Mode:   git.HardReset,
+	}); err != nil {
+		return fmt.Errorf("%w: %w", errBrokenRepository, err)
+	}
+	return nil
+}
+
+// wrapLocalObjectError wraps the error caused by the object store or the references of the local repository
+// such as the missing object of the shallow clone or the corrupt packfile with errBrokenRepository,
+// so that the repository is cloned again. Transport errors are returned as is.
+func wrapLocalObjectError(err error) error {
+	var (
+		packErr *packfile.Error
+		pathErr *fs.PathError
+	)
+	if errors.Is(err, plumbing.ErrObjectNotFound) ||
+		errors.Is(err, plumbing.ErrInvalidType) ||
+		errors.Is(err, packfile.ErrReferenceDeltaNotFound) ||
+		errors.Is(err, packfile.ErrInvalidDelta) ||
+		errors.Is(err, packfile.ErrDeltaCmd) ||
+		errors.Is(err, packfile.ErrDeltaNotCached) ||
+		errors.As(err, &packErr) ||
+		errors.As(err, &pathErr) {
+		return fmt.Errorf("%w: %w", errBrokenRepository, err)
+	}
+	return err
+}
+
+// defaultBranchName returns the branch name pointed to by the remote HEAD.
+// If the remote does not advertise HEAD as a symbolic reference, the branch having the same hash as HEAD is used.
+func defaultBranchName(refs []*plumbing.Reference) (plumbing.ReferenceName, error) {
+	var head *plumbing.Reference
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD {
+			head = ref
+			break
+		}
+	}
+	if head == nil {
+		return "", transport.ErrEmptyRemoteRepository
+	}
+	if head.Type() == plumbing.SymbolicReference {
+		return head.Target(), nil
+	}
+	for _, ref := range refs {
+		if ref.Name().IsBranch() && ref.Hash() == head.Hash() {
+			return ref.Name(), nil
+		}
+	}
+	return "", fmt.Errorf("failed to find default branch of remote repository")
+}
+
+// authMethod returns the authentication method for the url.
+// For SSH urls, the private key file is used if keyPath is specified, otherwise ssh-agent is used.
+// For other urls, the basic auth is used.
+func authMethod(url string, auth *BasicAuth, keyPath, keyPassphrase string) (transport.AuthMethod, error) {
+	if !isSSHURL(url) {
+		if auth == nil {
+			return nil, nil
+		}
+		return auth, nil
+	}
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, err
+	}
+	user := endpoint.User
+	if user == "" {
+		user = "git"
+	}
+	if keyPath != "" {
+		keys, err := ssh.NewPublicKeysFromFile(user, keyPath, keyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ssh key from %s: %w", keyPath, err)
+		}
+		return keys, nil
+	}
+	agentAuth, err := ssh.NewSSHAgentAuth(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use ssh-agent: %w", err)
+	}
+	return agentAuth, nil
+}
+
+// openLocalRepository opens the repository containing path, searching parent directories for .git.
+func openLocalRepository(path string) (*git.Repository, error) {
+	return plainOpenWithOptions(path, &git.PlainOpenOptions{DetectDotGit: true})
+}
+
+// localRemoteURL returns the url of the origin remote of the repository containing path
+// and the slash-separated subdirectory of path in the repository. e.g.) tools/linter
+// If the remote cannot be found, returns empty string.
+func localRemoteURL(path string) (string, string) {
+	repo, err := openLocalRepository(path)
+	if err != nil {
+		return "", ""
+	}
+	remote, err := repo.Remote(defaultRemoteName)
+	if err != nil {
+		return "", ""
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", ""
+	}
+	var subdir string
+	if wt, err := repo.Worktree(); err == nil {
+		if rel, err := filepath.Rel(wt.Filesystem.Root(), path); err == nil && rel != "." {
+			subdir = filepath.ToSlash(rel)
+		}
+	}
+	return urls[0], subdir
+}
+
+// localHeadCommit returns the head commit of the repository containing path.
+// If path is not managed by git, returns empty string.
+func localHeadCommit(path string) (string, error) {
+	repo, err := openLocalRepository(path)
+	if err != nil {
+		if errors.Is(err, git.ErrRepositoryNotExists) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to open repository from %s: %w", path, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// goModFileNames is the list of file names required to run `go mod graph`.
+var goModFileNames = map[string]struct{}{
+	"go.mod":      {},
+	"go.sum":      {},
+	"go.work":     {},
+	"go.work.sum": {},
+}
+
+// mirrorHeadCommit returns the head commit of the default branch of the bare or non-bare repository.
+func mirrorHeadCommit(mirrorPath string) (string, error) {
+	repo, err := plainOpen(mirrorPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open repository from %s: %w", mirrorPath, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// extractGoModFiles reads the files required to run `go mod graph` from the default branch tree
+// in the object store of mirrorPath and writes them under path.
+func extractGoModFiles(mirrorPath, path string) error {
+	repo, err := plainOpen(mirrorPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository from %s: %w", mirrorPath, err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return ErrEmptyRemoteRepository
+		}
+		return err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+	return tree.Files().ForEach(func(f *object.File) error {
+		if _, exists := goModFileNames[filepath.Base(f.Name)]; !exists {
+			return nil
+		}
+		content, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(path, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(dst, []byte(content), 0o644)
+	})
+}
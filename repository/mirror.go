@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-modrank/internal/helper"
+)
+
+// NewMirror creates the repository from the bare or non-bare git repository that already exists on the local filesystem.
+// Instead of cloning, only the files required to run `go mod graph` such as go.mod and go.sum are
+// read from the default branch tree in the object store and written under the clone path, so no network access is required.
+// The identity of the repository is derived from the url of the origin remote.
+// If the repository has no origin remote, "local" is used as the host name and the parent directory name as the owner name.
+func NewMirror(path string, opts ...Option) (*Repository, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return newMirror(filepath.Dir(absPath), absPath, opts...)
+}
+
+// SkippedPath is the path skipped by FindMirrors because it cannot be read.
+type SkippedPath struct {
+	Path string
+	Err  error
+}
+
+// FindMirrors walks the root directory and returns all bare or non-bare git repositories found under it.
+// If a repository has no origin remote, its identity is derived from the relative path from root.
+// e.g.) root/github.com/goccy/go-modrank.git => github.com/goccy/go-modrank
+// The directories which cannot be read such as permission denied are skipped and returned as the second value,
+// so that the caller can report them by its own logger.
+func FindMirrors(root string, opts ...Option) ([]*Repository, []*SkippedPath, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		repos   []*Repository
+		skipped []*SkippedPath
+	)
+	if err := filepath.WalkDir(absRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == absRoot {
+				return err
+			}
+			// the unreadable directory such as permission denied doesn't stop finding other repositories.
+			skipped = append(skipped, &SkippedPath{Path: path, Err: err})
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if !isGitRepository(path) {
+			return nil
+		}
+		repo, err := newMirror(absRoot, path, opts...)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, repo)
+		return filepath.SkipDir
+	}); err != nil {
+		return nil, nil, fmt.Errorf("failed to find repositories from %s: %w", root, err)
+	}
+	return repos, skipped, nil
+}
+
+func newMirror(root, path string, opts ...Option) (*Repository, error) {
+	if !isGitRepository(path) {
+		return nil, fmt.Errorf("%s is not a git repository", path)
+	}
+	url, _ := localRemoteURL(path)
+	parsedURL, err := parseURL(url)
+	if err != nil {
+		url = path
+		parsedURL = repositoryURLFromPath(root, path)
+	}
+	r := &Repository{
+		hostName:   parsedURL.host,
+		repoName:   parsedURL.name,
+		ownerName:  parsedURL.owner,
+		url:        url,
+		cloner:     new(DefaultCloner),
+		clonePath:  helper.TmpRoot,
+		weight:     DefaultRepositoryWeight,
+		mirrorPath: path,
+	}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// repositoryURLFromPath creates the repository identity from the relative path from root.
+func repositoryURLFromPath(root, path string) *repositoryURL {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	parts := strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, ".git")), "/")
+	name := parts[len(parts)-1]
+	switch len(parts) {
+	case 1:
+		return &repositoryURL{
+			host:  localHostName,
+			owner: filepath.Base(filepath.Dir(path)),
+			name:  name,
+		}
+	case 2:
+		return &repositoryURL{
+			host:  localHostName,
+			owner: parts[0],
+			name:  name,
+		}
+	}
+	return &repositoryURL{
+		host:  parts[0],
+		owner: strings.Join(parts[1:len(parts)-1], "/"),
+		name:  name,
+	}
+}
+
+// isGitRepository returns whether path is the root of a bare or non-bare git repository.
+func isGitRepository(path string) bool {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return true
+	}
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(path, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/goccy/go-modrank/repository"
+)
+
+func TestFindMirrors(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command is not installed")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	src := filepath.Join(root, "example.com", "owner", "src")
+	git(t, root, "init", "--quiet", "--initial-branch", "main", src)
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"go.mod", filepath.Join("sub", "go.mod"), "main.go"} {
+		if err := os.WriteFile(filepath.Join(src, file), []byte("module example.com/owner/src\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git(t, src, "add", ".")
+	git(t, src, "commit", "--quiet", "--message", "first")
+	git(t, root, "clone", "--quiet", "--bare", src, filepath.Join(root, "example.com", "owner", "bare.git"))
+	git(t, filepath.Join(root, "example.com", "owner", "bare.git"), "remote", "remove", "origin")
+
+	clonePath := t.TempDir()
+	repos, _, err := repository.FindMirrors(root, repository.WithClonePath(clonePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, repo := range repos {
+		names = append(names, repo.FullName())
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "example.com/owner/bare" || names[1] != "example.com/owner/src" {
+		t.Fatalf("unexpected repositories: %v", names)
+	}
+	for _, repo := range repos {
+		if repo.Name() != "bare" {
+			continue
+		}
+		if err := repo.Clone(ctx, repo.Path()); err != nil {
+			t.Fatal(err)
+		}
+		paths, err := repo.GoModPaths()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(paths) != 2 {
+			t.Fatalf("failed to extract go.mod files: %v", paths)
+		}
+		if _, err := os.Stat(filepath.Join(repo.Path(), "main.go")); err == nil {
+			t.Fatal("unexpected file is extracted")
+		}
+		head, err := repo.HeadCommit(ctx, repo.Path())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := git(t, src, "rev-parse", "HEAD"); head != expected {
+			t.Fatalf("unexpected head commit: expected %s but got %s", expected, head)
+		}
+	}
+}
+
+func TestFindMirrorsSkipUnreadableDirectory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command is not installed")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("the permission is not checked for root user")
+	}
+	root := t.TempDir()
+	git(t, root, "init", "--quiet", filepath.Join(root, "example.com", "owner", "repo"))
+	unreadable := filepath.Join(root, "example.com", "unreadable")
+	if err := os.MkdirAll(unreadable, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(unreadable, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(unreadable, 0o755) })
+
+	repos, skipped, err := repository.FindMirrors(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].FullName() != "example.com/owner/repo" {
+		t.Fatalf("unexpected repositories: %v", repos)
+	}
+	if len(skipped) != 1 || skipped[0].Path != unreadable || skipped[0].Err == nil {
+		t.Fatalf("unexpected skipped paths: %v", skipped)
+	}
+}
@@ -15,6 +15,15 @@ func WithAuthToken(issuer TokenIssuer) Option {
 	}
 }
 
+// WithAuthUsername specify the username used with the token specified by WithAuthToken.
+// Default is "x-access-token" for GitHub. For GitLab, use "oauth2".
+func WithAuthUsername(name string) Option {
+	return func(r *Repository) error {
+		r.authUsername = name
+		return nil
+	}
+}
+
 type Cloner interface {
 	HeadCommit(ctx context.Context, path string) (string, error)
 	Clone(ctx context.Context, path, url string, auth *BasicAuth) error
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
-	"net/url"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -13,7 +13,11 @@ import (
 
 const DefaultRepositoryWeight = 1
 
+// defaultAuthUsername is the username to access GitHub repositories by token.
+const defaultAuthUsername = "x-access-token"
+
 type Repository struct {
+	hostName        string
 	repoName        string
 	ownerName       string
 	url             string
@@ -21,19 +25,24 @@ type Repository struct {
 	clonePath       string
 	weight          int
 	authTokenIssuer TokenIssuer
+	authUsername    string
+	localPath       string
+	localSubdir     string
+	mirrorPath      string
 }
 
+// New creates the repository from HTTPS, SSH or scp-like url.
+// The repository path without scheme such as github.com/goccy/go-modrank is cloned by HTTPS.
 func New(url string, opts ...Option) (*Repository, error) {
-	trimmedExt := strings.TrimSuffix(url, ".git")
-	parts := strings.Split(trimmedExt, "/")
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("unexpected repository url: %s", url)
+	url = normalizeURL(url)
+	parsedURL, err := parseURL(url)
+	if err != nil {
+		return nil, err
 	}
-	repoName := parts[len(parts)-1]
-	ownerName := parts[len(parts)-2]
 	r := &Repository{
-		repoName:  repoName,
-		ownerName: ownerName,
+		hostName:  parsedURL.host,
+		repoName:  parsedURL.name,
+		ownerName: parsedURL.owner,
 		url:       url,
 		cloner:    new(DefaultCloner),
 		clonePath: helper.TmpRoot,
@@ -47,8 +56,107 @@ func New(url string, opts ...Option) (*Repository, error) {
 	return r, nil
 }
 
+// NewLocal creates the repository from the directory that already exists on the local filesystem
+// such as a developer checkout or a CI workspace. The directory is scanned as is without cloning.
+// The identity of the repository is derived from the url of the origin remote.
+// If the directory is the subdirectory of the repository such as a module in a monorepo, the subdirectory is also included.
+// If the directory has no origin remote, "local" is used as the host name and the parent directory name as the owner name.
+func NewLocal(path string, opts ...Option) (*Repository, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("local repository path is not a directory: %s", path)
+	}
+	url, subdir := localRemoteURL(absPath)
+	parsedURL, err := parseURL(url)
+	if err != nil {
+		url = absPath
+		subdir = ""
+		parsedURL = &repositoryURL{
+			host:  localHostName,
+			owner: filepath.Base(filepath.Dir(absPath)),
+			name:  filepath.Base(absPath),
+		}
+	}
+	r := &Repository{
+		hostName:    parsedURL.host,
+		repoName:    parsedURL.name,
+		ownerName:   parsedURL.owner,
+		url:         url,
+		cloner:      new(DefaultCloner),
+		weight:      DefaultRepositoryWeight,
+		localPath:   absPath,
+		localSubdir: subdir,
+	}
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// localHostName is the host name used for local repositories without remote.
+const localHostName = "local"
+
+// Path returns the path to clone the repository. e.g.) clonePath/github.com/goccy/go-modrank
+// For local repositories, returns the specified directory path.
 func (r *Repository) Path() string {
-	return filepath.Join(r.clonePath, r.repoName)
+	if r.IsLocal() {
+		return r.localPath
+	}
+	return filepath.Join(r.clonePath, r.hostName, r.ownerName, r.repoName)
+}
+
+// IsLocal returns whether the repository is created by NewLocal.
+func (r *Repository) IsLocal() bool {
+	return r.localPath != ""
+}
+
+// IsMirror returns whether the repository is created by NewMirror or FindMirrors.
+func (r *Repository) IsMirror() bool {
+	return r.mirrorPath != ""
+}
+
+// MigrateLegacyClonePath moves the repository cloned with the legacy layout (clonePath/repoName) to Path().
+// The legacy clone is moved only if it was cloned from the same URL and Path() does not exist yet.
+func (r *Repository) MigrateLegacyClonePath() error {
+	if r.IsLocal() || r.IsMirror() {
+		return nil
+	}
+	legacyPath := filepath.Join(r.clonePath, r.repoName)
+	path := r.Path()
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	repo, err := plainOpen(legacyPath)
+	if err != nil {
+		return nil
+	}
+	remote, err := repo.Remote(defaultRemoteName)
+	if err != nil {
+		return nil
+	}
+	if urls := remote.Config().URLs; len(urls) == 0 || urls[0] != r.url {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(legacyPath, path); err != nil {
+		return fmt.Errorf("failed to migrate cloned repository from %s to %s: %w", legacyPath, path, err)
+	}
+	return nil
+}
+
+func (r *Repository) Host() string {
+	return r.hostName
 }
 
 func (r *Repository) Owner() string {
@@ -63,6 +171,17 @@ func (r *Repository) NameWithOwner() string {
 	return r.ownerName + "/" + r.repoName
 }
 
+// FullName returns the name including the host name to identify the repository across hosts.
+// e.g.) github.com/goccy/go-modrank
+// For local repositories created from the subdirectory, the subdirectory is appended. e.g.) github.com/goccy/go-modrank/tools
+func (r *Repository) FullName() string {
+	name := r.hostName + "/" + r.NameWithOwner()
+	if r.localSubdir != "" {
+		name += "/" + r.localSubdir
+	}
+	return name
+}
+
 func (r *Repository) Weight() int {
 	return r.weight
 }
@@ -72,19 +191,40 @@ func (r *Repository) URL() string {
 }
 
 func (r *Repository) HeadCommit(ctx context.Context, path string) (string, error) {
+	if r.IsLocal() {
+		return localHeadCommit(path)
+	}
+	if r.IsMirror() {
+		return mirrorHeadCommit(r.mirrorPath)
+	}
 	return r.cloner.HeadCommit(ctx, path)
 }
 
+// Clone clones the repository to the path. For local repositories, it does nothing.
+// For mirror repositories, it writes only go.mod and related files of the default branch to the path.
 func (r *Repository) Clone(ctx context.Context, path string) error {
+	if r.IsLocal() {
+		return nil
+	}
+	if r.IsMirror() {
+		return extractGoModFiles(r.mirrorPath, path)
+	}
 	var auth *BasicAuth
 	if r.authTokenIssuer != nil {
 		tk, err := r.authTokenIssuer(ctx)
 		if err != nil {
 			return fmt.Errorf("modrank: failed to issue token to access git repository: %w", err)
 		}
-		auth = &BasicAuth{
-			Username: "x-access-token",
-			Password: tk,
+		username := r.authUsername
+		if username == "" {
+			username = defaultAuthUsername
+		}
+		// access public repositories anonymously if the token is empty.
+		if tk != "" {
+			auth = &BasicAuth{
+				Username: username,
+				Password: tk,
+			}
 		}
 	}
 	return r.cloner.Clone(ctx, path, r.url, auth)
@@ -92,7 +232,8 @@ func (r *Repository) Clone(ctx context.Context, path string) error {
 
 func (r *Repository) GoModPaths() ([]string, error) {
 	var paths []string
-	if err := filepath.Walk(r.Path(), func(path string, info fs.FileInfo, err error) error {
+	root := r.Path()
+	if err := filepath.Walk(root, func(path string, info fs.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -100,7 +241,7 @@ func (r *Repository) GoModPaths() ([]string, error) {
 			return nil
 		}
 		// ignore vendor path.
-		if r.isVendorPath(path) {
+		if r.isVendorPath(strings.TrimPrefix(path, root)) {
 			return nil
 		}
 		if filepath.Base(path) == "go.mod" {
@@ -113,12 +254,10 @@ func (r *Repository) GoModPaths() ([]string, error) {
 	return paths, nil
 }
 
+// IsGitHubRepository returns whether the repository is hosted by GitHub.com.
+// GitHub Enterprise Server hosts are configured by GitHubClient instead.
 func (r *Repository) IsGitHubRepository() bool {
-	parsedURL, err := url.Parse(r.url)
-	if err != nil {
-		return false
-	}
-	return parsedURL.Host == "github.com"
+	return r.hostName == "github.com"
 }
 
 func (r *Repository) isVendorPath(path string) bool {
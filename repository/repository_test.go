@@ -0,0 +1,184 @@
+package repository_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-modrank/repository"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		url      string
+		host     string
+		owner    string
+		name     string
+		fullName string
+		isGitHub bool
+	}{
+		{
+			url:      "https://github.com/goccy/go-modrank.git",
+			host:     "github.com",
+			owner:    "goccy",
+			name:     "go-modrank",
+			fullName: "github.com/goccy/go-modrank",
+			isGitHub: true,
+		},
+		{
+			url:      "ssh://git@github.com:22/goccy/go-modrank.git",
+			host:     "github.com",
+			owner:    "goccy",
+			name:     "go-modrank",
+			fullName: "github.com/goccy/go-modrank",
+			isGitHub: true,
+		},
+		{
+			url:      "git@github.com:goccy/go-modrank.git",
+			host:     "github.com",
+			owner:    "goccy",
+			name:     "go-modrank",
+			fullName: "github.com/goccy/go-modrank",
+			isGitHub: true,
+		},
+		{
+			url:      "github.com/goccy/go-modrank",
+			host:     "github.com",
+			owner:    "goccy",
+			name:     "go-modrank",
+			fullName: "github.com/goccy/go-modrank",
+			isGitHub: true,
+		},
+		{
+			url:      "git@gitlab.com:group/subgroup/project",
+			host:     "gitlab.com",
+			owner:    "group/subgroup",
+			name:     "project",
+			fullName: "gitlab.com/group/subgroup/project",
+		},
+		{
+			url:      "https://gitlab.example.com/group/subgroup/project.git",
+			host:     "gitlab.example.com",
+			owner:    "group/subgroup",
+			name:     "project",
+			fullName: "gitlab.example.com/group/subgroup/project",
+		},
+		{
+			url:      "https://git.example.com:8443/owner/repo.git",
+			host:     "git.example.com:8443",
+			owner:    "owner",
+			name:     "repo",
+			fullName: "git.example.com:8443/owner/repo",
+		},
+		{
+			url:      "https://git.example.com:443/owner/repo.git",
+			host:     "git.example.com",
+			owner:    "owner",
+			name:     "repo",
+			fullName: "git.example.com/owner/repo",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			repo, err := repository.New(test.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if repo.Host() != test.host {
+				t.Errorf("unexpected host: expected %s but got %s", test.host, repo.Host())
+			}
+			if repo.Owner() != test.owner {
+				t.Errorf("unexpected owner: expected %s but got %s", test.owner, repo.Owner())
+			}
+			if repo.Name() != test.name {
+				t.Errorf("unexpected name: expected %s but got %s", test.name, repo.Name())
+			}
+			if repo.FullName() != test.fullName {
+				t.Errorf("unexpected full name: expected %s but got %s", test.fullName, repo.FullName())
+			}
+			if repo.IsGitHubRepository() != test.isGitHub {
+				t.Errorf("unexpected GitHub repository status: expected %t", test.isGitHub)
+			}
+		})
+	}
+	t.Run("invalid url", func(t *testing.T) {
+		for _, url := range []string{"github.com/goccy", "https://github.com/goccy", "goccy/go-modrank"} {
+			if _, err := repository.New(url); err == nil {
+				t.Errorf("expected error for %s", url)
+			}
+		}
+	})
+}
+
+func TestMigrateLegacyClonePath(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command is not installed")
+	}
+	const url = "https://github.com/goccy/go-modrank.git"
+	tests := []struct {
+		name     string
+		origin   string
+		migrated bool
+	}{
+		{name: "same url", origin: url, migrated: true},
+		{name: "different url", origin: "https://github.com/other/go-modrank.git"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clonePath := t.TempDir()
+			legacyPath := filepath.Join(clonePath, "go-modrank")
+			git(t, clonePath, "init", "--quiet", legacyPath)
+			git(t, legacyPath, "remote", "add", "origin", test.origin)
+
+			repo, err := repository.New(url, repository.WithClonePath(clonePath))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.MigrateLegacyClonePath(); err != nil {
+				t.Fatal(err)
+			}
+			_, err = os.Stat(filepath.Join(repo.Path(), ".git"))
+			if migrated := err == nil; migrated != test.migrated {
+				t.Fatalf("unexpected migration status: expected %t", test.migrated)
+			}
+			if _, err := os.Stat(legacyPath); (err == nil) == test.migrated {
+				t.Fatalf("unexpected legacy clone status: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewLocal(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command is not installed")
+	}
+	root := t.TempDir()
+	git(t, root, "init", "--quiet")
+	git(t, root, "remote", "add", "origin", "https://github.com/goccy/go-modrank.git")
+	for _, dir := range []string{"a", filepath.Join("b", "c")} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		path     string
+		fullName string
+	}{
+		{path: root, fullName: "github.com/goccy/go-modrank"},
+		{path: filepath.Join(root, "a"), fullName: "github.com/goccy/go-modrank/a"},
+		{path: filepath.Join(root, "b", "c"), fullName: "github.com/goccy/go-modrank/b/c"},
+	}
+	for _, test := range tests {
+		repo, err := repository.NewLocal(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repo.FullName() != test.fullName {
+			t.Errorf("unexpected full name: expected %s but got %s", test.fullName, repo.FullName())
+		}
+		if repo.NameWithOwner() != "goccy/go-modrank" {
+			t.Errorf("unexpected name with owner: %s", repo.NameWithOwner())
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// scpLikeURLPat matches scp-like syntax supported by git. e.g.) git@github.com:goccy/go-modrank.git
+var scpLikeURLPat = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// schemelessURLPat matches the repository path without scheme accepted as HTTPS url. e.g.) github.com/goccy/go-modrank
+var schemelessURLPat = regexp.MustCompile(`^[^@:/]+\.[^@:/]+/[^:]+$`)
+
+type repositoryURL struct {
+	host  string
+	owner string
+	name  string
+}
+
+// normalizeURL returns the HTTPS url for the repository path without scheme. Otherwise, returns the url as is.
+func normalizeURL(v string) string {
+	if !strings.Contains(v, "://") && schemelessURLPat.MatchString(v) {
+		return "https://" + v
+	}
+	return v
+}
+
+// URLHost returns the host of the repositories served by the url.
+// The port is kept to distinguish the instances on the same host name unless it's the default port of the scheme.
+// e.g.) https://git.example.com:8443 => git.example.com:8443, https://git.example.com:443 => git.example.com
+// The port of SSH url is dropped because it's the port of the SSH server, not of the web site.
+func URLHost(u *url.URL) string {
+	port := u.Port()
+	switch {
+	case port == "",
+		u.Scheme == "https" && port == "443",
+		u.Scheme == "http" && port == "80",
+		u.Scheme == "ssh" || u.Scheme == "git+ssh":
+		return u.Hostname()
+	}
+	return u.Host
+}
+
+// parseURL parses HTTPS, SSH and scp-like repository urls.
+// If the repository is nested in groups such as GitLab subgroups, owner contains all of them. e.g.) group/subgroup
+func parseURL(v string) (*repositoryURL, error) {
+	var host, path string
+	if strings.Contains(v, "://") {
+		parsedURL, err := url.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected repository url: %s: %w", v, err)
+		}
+		host = URLHost(parsedURL)
+		path = parsedURL.Path
+	} else if matched := scpLikeURLPat.FindStringSubmatch(v); len(matched) == 3 {
+		host = matched[1]
+		path = matched[2]
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+	if host == "" || len(parts) < 2 {
+		return nil, fmt.Errorf("unexpected repository url: %s", v)
+	}
+	return &repositoryURL{
+		host:  host,
+		owner: strings.Join(parts[:len(parts)-1], "/"),
+		name:  parts[len(parts)-1],
+	}, nil
+}
+
+// isSSHURL returns whether the url uses SSH protocol including scp-like syntax.
+func isSSHURL(v string) bool {
+	if strings.Contains(v, "://") {
+		return strings.HasPrefix(v, "ssh://") || strings.HasPrefix(v, "git+ssh://")
+	}
+	return scpLikeURLPat.MatchString(v)
+}
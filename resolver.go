@@ -0,0 +1,148 @@
+package modrank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultHostedRepositoryTimeout is the timeout of each built-in strategy to resolve one module.
+	defaultHostedRepositoryTimeout = 10 * time.Second
+	// defaultHostedRepositoryConcurrency is the max number of modules resolved concurrently by each built-in strategy.
+	defaultHostedRepositoryConcurrency = 8
+)
+
+// HostedRepositoryResolver resolves the root of the repository hosting the Go module.
+// If the resolver cannot resolve the module, it returns nil without error
+// so that the next resolver in the chain is tried.
+type HostedRepositoryResolver interface {
+	ResolveHostedRepository(ctx context.Context, modulePath string) (*HostedRepositoryRoot, error)
+}
+
+// HostedRepositoryResolverFunc is the adapter to use a function as HostedRepositoryResolver.
+type HostedRepositoryResolverFunc func(ctx context.Context, modulePath string) (*HostedRepositoryRoot, error)
+
+func (f HostedRepositoryResolverFunc) ResolveHostedRepository(ctx context.Context, modulePath string) (*HostedRepositoryRoot, error) {
+	return f(ctx, modulePath)
+}
+
+// HostedRepositoryStrategy is the element of the resolver chain created by NewChainHostedRepositoryResolver.
+type HostedRepositoryStrategy struct {
+	// Name is used for logging. e.g.) goproxy
+	Name     string
+	Resolver HostedRepositoryResolver
+	// Timeout is the timeout to resolve one module. If zero, there is no timeout other than the context.
+	Timeout time.Duration
+	// Concurrency is the max number of modules resolved concurrently by this strategy. If zero, there is no limit.
+	Concurrency int
+
+	sem chan struct{}
+}
+
+func (s *HostedRepositoryStrategy) resolve(ctx context.Context, modulePath string) (*HostedRepositoryRoot, error) {
+	if s.sem != nil {
+		select {
+		case s.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		defer func() { <-s.sem }()
+	}
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	return s.Resolver.ResolveHostedRepository(ctx, modulePath)
+}
+
+type chainHostedRepositoryResolver struct {
+	strategies []*HostedRepositoryStrategy
+}
+
+// NewChainHostedRepositoryResolver creates the resolver trying the strategies in order until one of them resolves the module.
+// The error of a strategy is logged and the next strategy is tried, but the cancellation of the context stops the chain.
+// If no strategy resolves the module, it returns nil without error only if no strategy failed,
+// otherwise it returns the errors of the failed strategies because the module might be resolved by them.
+func NewChainHostedRepositoryResolver(strategies ...*HostedRepositoryStrategy) HostedRepositoryResolver {
+	for _, s := range strategies {
+		if s.Concurrency > 0 && s.sem == nil {
+			s.sem = make(chan struct{}, s.Concurrency)
+		}
+	}
+	return &chainHostedRepositoryResolver{strategies: strategies}
+}
+
+func (r *chainHostedRepositoryResolver) ResolveHostedRepository(ctx context.Context, modulePath string) (*HostedRepositoryRoot, error) {
+	var errs []error
+	for _, s := range r.strategies {
+		root, err := s.resolve(ctx, modulePath)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			logger(ctx).DebugContext(ctx, "failed to resolve hosted repository", "strategy", s.Name, "module", modulePath, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name, err))
+			continue
+		}
+		if root != nil {
+			return root, nil
+		}
+	}
+	if len(errs) != 0 {
+		return nil, fmt.Errorf("failed to resolve hosted repository of %s: %w", modulePath, errors.Join(errs...))
+	}
+	return nil, nil
+}
+
+// StaticHostedRepositoryResolver resolves the hosted repository by the mapping from the module path specified manually.
+// e.g.) StaticHostedRepositoryResolver(map[string]string{"example.com/mod": "github.com/owner/mod"})
+func StaticHostedRepositoryResolver(mapping map[string]string) HostedRepositoryResolver {
+	return HostedRepositoryResolverFunc(func(_ context.Context, modulePath string) (*HostedRepositoryRoot, error) {
+		repo, exists := mapping[modulePath]
+		if !exists {
+			return nil, nil
+		}
+		return &HostedRepositoryRoot{Prefix: modulePath, Repository: repo}, nil
+	})
+}
+
+// newDefaultHostedRepositoryResolver creates the resolver chain of the custom strategies and the built-in strategies:
+// the Go module proxy, the gopkg.in rule, the known hosts such as github.com and the go-import meta tag.
+func newDefaultHostedRepositoryResolver(hc *http.Client, proxy *goProxy, timeout time.Duration, concurrency int, custom []*HostedRepositoryStrategy) HostedRepositoryResolver {
+	strategies := append([]*HostedRepositoryStrategy{}, custom...)
+	strategies = append(strategies,
+		&HostedRepositoryStrategy{
+			Name: "goproxy",
+			Resolver: HostedRepositoryResolverFunc(func(ctx context.Context, modulePath string) (*HostedRepositoryRoot, error) {
+				return proxy.resolve(ctx, hc, modulePath)
+			}),
+			Timeout:     timeout,
+			Concurrency: concurrency,
+		},
+		&HostedRepositoryStrategy{
+			Name: "gopkg.in",
+			Resolver: HostedRepositoryResolverFunc(func(_ context.Context, modulePath string) (*HostedRepositoryRoot, error) {
+				return getHostedRepositoryByGoPkgIn(modulePath)
+			}),
+		},
+		&HostedRepositoryStrategy{
+			Name: "known-host",
+			Resolver: HostedRepositoryResolverFunc(func(_ context.Context, modulePath string) (*HostedRepositoryRoot, error) {
+				return getHostedRepositoryByKnownHost(modulePath)
+			}),
+		},
+		&HostedRepositoryStrategy{
+			Name: "go-import",
+			Resolver: HostedRepositoryResolverFunc(func(ctx context.Context, modulePath string) (*HostedRepositoryRoot, error) {
+				return getHostedRepositoryByGoImportMetaTag(ctx, hc, proxy, modulePath)
+			}),
+			Timeout:     timeout,
+			Concurrency: concurrency,
+		},
+	)
+	return NewChainHostedRepositoryResolver(strategies...)
+}
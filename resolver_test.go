@@ -0,0 +1,102 @@
+package modrank_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-modrank"
+)
+
+func TestChainHostedRepositoryResolver(t *testing.T) {
+	slow := &modrank.HostedRepositoryStrategy{
+		Name: "slow",
+		Resolver: modrank.HostedRepositoryResolverFunc(func(ctx context.Context, _ string) (*modrank.HostedRepositoryRoot, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+		Timeout: 10 * time.Millisecond,
+	}
+	static := &modrank.HostedRepositoryStrategy{
+		Name: "static",
+		Resolver: modrank.StaticHostedRepositoryResolver(map[string]string{
+			"example.com/mod": "github.com/owner/mod",
+		}),
+	}
+
+	t.Run("fallback after timeout", func(t *testing.T) {
+		resolver := modrank.NewChainHostedRepositoryResolver(slow, static)
+		got, err := resolver.ResolveHostedRepository(context.Background(), "example.com/mod")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil || got.Repository != "github.com/owner/mod" {
+			t.Fatalf("unexpected hosted repository: %+v", got)
+		}
+	})
+	t.Run("not resolved", func(t *testing.T) {
+		resolver := modrank.NewChainHostedRepositoryResolver(static)
+		got, err := resolver.ResolveHostedRepository(context.Background(), "example.com/unknown")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != nil {
+			t.Fatalf("unexpected hosted repository: %+v", got)
+		}
+	})
+	t.Run("failed", func(t *testing.T) {
+		resolver := modrank.NewChainHostedRepositoryResolver(slow, static)
+		got, err := resolver.ResolveHostedRepository(context.Background(), "example.com/unknown")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != nil {
+			t.Fatalf("unexpected hosted repository: %+v", got)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		resolver := modrank.NewChainHostedRepositoryResolver(slow, static)
+		if _, err := resolver.ResolveHostedRepository(ctx, "example.com/mod"); !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("concurrency", func(t *testing.T) {
+		var (
+			running int32
+			maxRun  int32
+		)
+		resolver := modrank.NewChainHostedRepositoryResolver(&modrank.HostedRepositoryStrategy{
+			Name: "limited",
+			Resolver: modrank.HostedRepositoryResolverFunc(func(_ context.Context, name string) (*modrank.HostedRepositoryRoot, error) {
+				cur := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					prev := atomic.LoadInt32(&maxRun)
+					if cur <= prev || atomic.CompareAndSwapInt32(&maxRun, prev, cur) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				return &modrank.HostedRepositoryRoot{Prefix: name, Repository: name}, nil
+			}),
+			Concurrency: 2,
+		})
+		done := make(chan struct{})
+		for range 6 {
+			go func() {
+				_, _ = resolver.ResolveHostedRepository(context.Background(), "example.com/mod")
+				done <- struct{}{}
+			}()
+		}
+		for range 6 {
+			<-done
+		}
+		if maxRun > 2 {
+			t.Fatalf("exceeded concurrency limit: %d", maxRun)
+		}
+	})
+}
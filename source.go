@@ -0,0 +1,290 @@
+package modrank
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-modrank/repository"
+)
+
+// RepositorySource is the interface to discover the repositories to scan.
+// Implement this interface to plug in your own discovery such as a service catalog.
+type RepositorySource interface {
+	Repositories(ctx context.Context) ([]*SourceRepository, error)
+}
+
+// SourceRepository represents the repository discovered by RepositorySource with its metadata.
+// The weight of the repository is specified by repository.WithWeight option.
+type SourceRepository struct {
+	Repository *repository.Repository
+	// IsArchived whether the repository is archived. Archived repositories are not scanned.
+	IsArchived bool
+	// DefaultBranch is the default branch name of the repository if the source knows it.
+	DefaultBranch string
+	// Topics is the list of topics of the repository if the source knows it.
+	Topics []string
+	// Stars is the number of stars of the repository if the source knows it.
+	Stars int
+}
+
+// FindRepositories collects repositories with their metadata from all sources.
+// Archived repositories are excluded and repositories found by multiple sources are deduplicated by the first one.
+func FindRepositories(ctx context.Context, sources ...RepositorySource) ([]*SourceRepository, error) {
+	var (
+		repos   []*SourceRepository
+		seenMap = make(map[string]struct{})
+	)
+	for _, src := range sources {
+		srcRepos, err := src.Repositories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, srcRepo := range srcRepos {
+			if srcRepo.IsArchived {
+				continue
+			}
+			name := srcRepo.Repository.FullName()
+			if _, exists := seenMap[name]; exists {
+				continue
+			}
+			seenMap[name] = struct{}{}
+			repos = append(repos, srcRepo)
+		}
+	}
+	return repos, nil
+}
+
+// RepositorySourceFunc is an adapter to allow the use of ordinary functions as RepositorySource.
+type RepositorySourceFunc func(ctx context.Context) ([]*SourceRepository, error)
+
+func (f RepositorySourceFunc) Repositories(ctx context.Context) ([]*SourceRepository, error) {
+	return f(ctx)
+}
+
+// NewGitHubOrganizationSource creates the source to discover all repositories in the GitHub organization.
+func NewGitHubOrganizationSource(client *GitHubClient, org string, opts ...repository.Option) RepositorySource {
+	return NewGitHubOwnerSource(client, org, nil, opts...)
+}
+
+// NewGitHubOwnerSource creates the source to discover repositories owned by the GitHub organization or user
+// that match the filter. If the filter is nil, all repositories are discovered.
+func NewGitHubOwnerSource(client *GitHubClient, owner string, filter *GitHubRepositoryFilter, opts ...repository.Option) RepositorySource {
+	return RepositorySourceFunc(func(ctx context.Context) ([]*SourceRepository, error) {
+		githubRepos, err := client.FindRepositoriesByOwner(ctx, owner, filter)
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]*SourceRepository, 0, len(githubRepos))
+		for _, githubRepo := range githubRepos {
+			repoOwner, repoName, _ := strings.Cut(githubRepo.NameWithOwner, "/")
+			repo, err := repository.New(client.RepositoryURL(repoOwner, repoName), opts...)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, &SourceRepository{
+				Repository:    repo,
+				IsArchived:    githubRepo.IsArchived,
+				DefaultBranch: githubRepo.DefaultBranch,
+				Topics:        githubRepo.Topics,
+				Stars:         githubRepo.Stars,
+			})
+		}
+		return ret, nil
+	})
+}
+
+// NewGitLabGroupSource creates the source to discover all projects in the GitLab group including its subgroups.
+// Discovered repositories are cloned with the GitLab access token of the client as oauth2 user.
+// Archived projects are marked as archived.
+func NewGitLabGroupSource(client *GitLabClient, group string, opts ...repository.Option) RepositorySource {
+	return RepositorySourceFunc(func(ctx context.Context) ([]*SourceRepository, error) {
+		projects, err := client.FindProjectsByGroup(ctx, group)
+		if err != nil {
+			return nil, err
+		}
+		// the GitLab access token takes precedence over the token specified by opts.
+		repoOpts := append(append([]repository.Option{}, opts...), cloneAuthOptions(client)...)
+		ret := make([]*SourceRepository, 0, len(projects))
+		for _, project := range projects {
+			repo, err := repository.New(project.HTTPURLToRepo, repoOpts...)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, &SourceRepository{
+				Repository:    repo,
+				IsArchived:    project.Archived,
+				DefaultBranch: project.DefaultBranch,
+			})
+		}
+		return ret, nil
+	})
+}
+
+// NewGiteaOrganizationSource creates the source to discover all repositories in the Gitea organization.
+// Discovered repositories are cloned with the Gitea access token of the client.
+// Archived repositories are marked as archived.
+func NewGiteaOrganizationSource(client *GiteaClient, org string, opts ...repository.Option) RepositorySource {
+	return RepositorySourceFunc(func(ctx context.Context) ([]*SourceRepository, error) {
+		giteaRepos, err := client.FindRepositoriesByOrganization(ctx, org)
+		if err != nil {
+			return nil, err
+		}
+		// the Gitea access token takes precedence over the token specified by opts.
+		repoOpts := append(append([]repository.Option{}, opts...), cloneAuthOptions(client)...)
+		ret := make([]*SourceRepository, 0, len(giteaRepos))
+		for _, giteaRepo := range giteaRepos {
+			repo, err := repository.New(giteaRepo.CloneURL, repoOpts...)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, &SourceRepository{
+				Repository:    repo,
+				IsArchived:    giteaRepo.Archived,
+				DefaultBranch: giteaRepo.DefaultBranch,
+			})
+		}
+		return ret, nil
+	})
+}
+
+// NewBitbucketWorkspaceSource creates the source to discover all repositories in the Bitbucket Cloud workspace.
+// Discovered repositories are cloned with the Bitbucket access token of the client.
+func NewBitbucketWorkspaceSource(client *BitbucketClient, workspace string, opts ...repository.Option) RepositorySource {
+	return RepositorySourceFunc(func(ctx context.Context) ([]*SourceRepository, error) {
+		bitbucketRepos, err := client.FindRepositoriesByWorkspace(ctx, workspace)
+		if err != nil {
+			return nil, err
+		}
+		// the Bitbucket access token takes precedence over the token specified by opts.
+		repoOpts := append(append([]repository.Option{}, opts...), cloneAuthOptions(client)...)
+		ret := make([]*SourceRepository, 0, len(bitbucketRepos))
+		for _, bitbucketRepo := range bitbucketRepos {
+			repo, err := repository.New(bitbucketRepo.CloneURL(), repoOpts...)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, &SourceRepository{
+				Repository:    repo,
+				DefaultBranch: bitbucketRepo.DefaultBranch(),
+			})
+		}
+		return ret, nil
+	})
+}
+
+// cloneAuthOptions returns the options to clone the repositories discovered from the host with its credential.
+func cloneAuthOptions(cred RepositoryCredential) []repository.Option {
+	issuer, username := cred.CloneAuth()
+	return []repository.Option{
+		repository.WithAuthToken(issuer),
+		repository.WithAuthUsername(username),
+	}
+}
+
+// NewStaticSource creates the source from the list of repository addresses.
+// If the address has neither a scheme nor scp-like syntax, https:// is completed. e.g.) github.com/goccy/go-modrank
+func NewStaticSource(urls []string, opts ...repository.Option) RepositorySource {
+	return RepositorySourceFunc(func(_ context.Context) ([]*SourceRepository, error) {
+		ret := make([]*SourceRepository, 0, len(urls))
+		for _, url := range urls {
+			repo, err := repository.New(url, opts...)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, &SourceRepository{Repository: repo})
+		}
+		return ret, nil
+	})
+}
+
+// NewFileSource creates the source from the file listing repository addresses.
+// Each line has the repository address and the optional weight separated by spaces.
+// Empty lines and lines starting with # are ignored.
+//
+//	# address [weight]
+//	github.com/goccy/go-modrank 10
+//	git@github.com:goccy/go-yaml.git
+func NewFileSource(path string, opts ...repository.Option) RepositorySource {
+	return RepositorySourceFunc(func(_ context.Context) ([]*SourceRepository, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		ret, err := parseRepositoryList(content, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return ret, nil
+	})
+}
+
+// NewLocalSource creates the source from local directories. See repository.NewLocal for details.
+func NewLocalSource(paths []string, opts ...repository.Option) RepositorySource {
+	return RepositorySourceFunc(func(_ context.Context) ([]*SourceRepository, error) {
+		ret := make([]*SourceRepository, 0, len(paths))
+		for _, path := range paths {
+			repo, err := repository.NewLocal(path, opts...)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, &SourceRepository{Repository: repo})
+		}
+		return ret, nil
+	})
+}
+
+// NewMirrorSource creates the source from git repositories under the root directory. See repository.FindMirrors for details.
+func NewMirrorSource(root string, opts ...repository.Option) RepositorySource {
+	return RepositorySourceFunc(func(ctx context.Context) ([]*SourceRepository, error) {
+		repos, skipped, err := repository.FindMirrors(root, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range skipped {
+			logger(ctx).WarnContext(ctx, "skip unreadable path to find repositories", "path", s.Path, "error", s.Err)
+		}
+		ret := make([]*SourceRepository, 0, len(repos))
+		for _, repo := range repos {
+			ret = append(ret, &SourceRepository{Repository: repo})
+		}
+		return ret, nil
+	})
+}
+
+func parseRepositoryList(content []byte, opts ...repository.Option) ([]*SourceRepository, error) {
+	var ret []*SourceRepository
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		repoOpts := opts
+		switch len(fields) {
+		case 1:
+		case 2:
+			weight, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid weight %q", lineNum, fields[1])
+			}
+			repoOpts = append(append([]repository.Option{}, opts...), repository.WithWeight(weight))
+		default:
+			return nil, fmt.Errorf("line %d: unexpected format %q", lineNum, line)
+		}
+		repo, err := repository.New(fields[0], repoOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		ret = append(ret, &SourceRepository{Repository: repo})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
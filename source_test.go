@@ -0,0 +1,79 @@
+package modrank_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-modrank"
+	"github.com/goccy/go-modrank/repository"
+)
+
+func TestFindRepositories(t *testing.T) {
+	ctx := context.Background()
+	listPath := filepath.Join(t.TempDir(), "repositories.txt")
+	if err := os.WriteFile(listPath, []byte(`
+# address [weight]
+github.com/goccy/go-yaml
+git@github.com:goccy/go-json.git 10
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	archived, err := repository.New("https://github.com/goccy/archived.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withMetadata, err := repository.New("https://github.com/goccy/go-graphviz.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, err := modrank.FindRepositories(
+		ctx,
+		modrank.NewStaticSource([]string{"github.com/goccy/go-modrank", "https://github.com/goccy/go-yaml.git"}),
+		modrank.NewFileSource(listPath),
+		modrank.RepositorySourceFunc(func(_ context.Context) ([]*modrank.SourceRepository, error) {
+			return []*modrank.SourceRepository{
+				{Repository: archived, IsArchived: true},
+				{Repository: withMetadata, DefaultBranch: "main", Topics: []string{"go"}, Stars: 10},
+			}, nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		name   string
+		weight int
+	}{
+		{name: "github.com/goccy/go-modrank", weight: repository.DefaultRepositoryWeight},
+		{name: "github.com/goccy/go-yaml", weight: repository.DefaultRepositoryWeight},
+		{name: "github.com/goccy/go-json", weight: 10},
+		{name: "github.com/goccy/go-graphviz", weight: repository.DefaultRepositoryWeight},
+	}
+	if len(repos) != len(expected) {
+		t.Fatalf("unexpected repository number: expected %d but got %d", len(expected), len(repos))
+	}
+	for idx, srcRepo := range repos {
+		repo := srcRepo.Repository
+		if repo.FullName() != expected[idx].name {
+			t.Errorf("unexpected repository: expected %s but got %s", expected[idx].name, repo.FullName())
+		}
+		if repo.Weight() != expected[idx].weight {
+			t.Errorf("unexpected weight of %s: expected %d but got %d", repo.FullName(), expected[idx].weight, repo.Weight())
+		}
+	}
+
+	// the metadata discovered by the source is kept.
+	if last := repos[len(repos)-1]; last.DefaultBranch != "main" || len(last.Topics) != 1 || last.Stars != 10 {
+		t.Fatalf("unexpected metadata: %+v", last)
+	}
+}
+
+func sourceRepositories(srcRepos []*modrank.SourceRepository) []*repository.Repository {
+	repos := make([]*repository.Repository, 0, len(srcRepos))
+	for _, srcRepo := range srcRepos {
+		repos = append(repos, srcRepo.Repository)
+	}
+	return repos
+}
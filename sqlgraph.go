@@ -0,0 +1,164 @@
+package modrank
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// sqlGoModuleGraph loads the dependency graph of Go modules from the go_modules and go_module_edges tables.
+// It is shared by the SQL storages, and the queries are specified by each dialect because of the placeholder syntax.
+// The loaded modules are cached so that the same module is always the same instance in the graph.
+type sqlGoModuleGraph struct {
+	db *sql.DB
+	// selectModules selects id, name_with_owner, go_mod_path, module_name, module_version, hosted_repository, subdirectory and is_root of all modules.
+	selectModules string
+	// selectModuleByID selects the same columns as selectModules of the module by id.
+	selectModuleByID string
+	// selectEdges selects caller_id and callee_id of all edges.
+	selectEdges string
+	// selectCallees selects callee_id of the edges by caller_id.
+	selectCallees string
+	// selectCallers selects caller_id of the edges by callee_id.
+	selectCallers string
+
+	cache   map[string]*GoModule
+	cacheMu sync.Mutex
+}
+
+// findRoots loads all modules and edges at once to build the dependency graph
+// instead of querying the referred modules one by one.
+func (g *sqlGoModuleGraph) findRoots(ctx context.Context) ([]*GoModule, error) {
+	mods, rootIDs, err := g.findModules(ctx, g.selectModules)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := g.db.QueryContext(ctx, g.selectEdges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var callerID, calleeID string
+		if err := rows.Scan(&callerID, &calleeID); err != nil {
+			return nil, err
+		}
+		caller, callee := mods[callerID], mods[calleeID]
+		if caller == nil || callee == nil {
+			continue
+		}
+		caller.Refers = append(caller.Refers, callee)
+		callee.Referers = append(callee.Referers, caller)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var roots []*GoModule
+	for id, mod := range mods {
+		sortGoModules(mod.Refers)
+		sortGoModules(mod.Referers)
+		if _, exists := rootIDs[id]; exists {
+			roots = append(roots, mod)
+		}
+	}
+	sortGoModules(roots)
+
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+	for id, mod := range mods {
+		g.cache[id] = mod
+	}
+	return roots, nil
+}
+
+func (g *sqlGoModuleGraph) findByID(ctx context.Context, id string) (*GoModule, error) {
+	g.cacheMu.Lock()
+	mod, exists := g.cache[id]
+	g.cacheMu.Unlock()
+	if exists {
+		return mod, nil
+	}
+
+	mods, _, err := g.findModules(ctx, g.selectModuleByID, id)
+	if err != nil {
+		return nil, err
+	}
+	mod = mods[id]
+	if mod == nil {
+		return nil, sql.ErrNoRows
+	}
+	g.cacheMu.Lock()
+	g.cache[id] = mod
+	g.cacheMu.Unlock()
+
+	refers, err := g.findEdgeModules(ctx, g.selectCallees, id)
+	if err != nil {
+		return nil, err
+	}
+	referers, err := g.findEdgeModules(ctx, g.selectCallers, id)
+	if err != nil {
+		return nil, err
+	}
+	mod.Refers = refers
+	mod.Referers = referers
+	return mod, nil
+}
+
+// findModules returns the modules by ID and the set of IDs of the root modules.
+func (g *sqlGoModuleGraph) findModules(ctx context.Context, query string, args ...any) (map[string]*GoModule, map[string]struct{}, error) {
+	rows, err := g.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	mods := make(map[string]*GoModule)
+	rootIDs := make(map[string]struct{})
+	for rows.Next() {
+		var (
+			mod    = &GoModule{}
+			isRoot bool
+		)
+		if err := rows.Scan(&mod.ID, &mod.Repository, &mod.GoModPath, &mod.Name, &mod.Version, &mod.HostedRepository, &mod.Subdirectory, &isRoot); err != nil {
+			return nil, nil, err
+		}
+		mods[mod.ID] = mod
+		if isRoot {
+			rootIDs[mod.ID] = struct{}{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return mods, rootIDs, nil
+}
+
+func (g *sqlGoModuleGraph) findEdgeModules(ctx context.Context, query, id string) ([]*GoModule, error) {
+	rows, err := g.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	mods := make([]*GoModule, 0, len(ids))
+	for _, id := range ids {
+		mod, err := g.findByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		mods = append(mods, mod)
+	}
+	sortGoModules(mods)
+	return mods, nil
+}
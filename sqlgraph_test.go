@@ -0,0 +1,87 @@
+package modrank
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLGoModuleGraph(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// the same schema as the Postgres and MySQL storages.
+	for _, stmt := range []string{
+		`
+CREATE TABLE go_modules (
+  id TEXT PRIMARY KEY,
+  name_with_owner TEXT NOT NULL,
+  go_mod_path TEXT NOT NULL,
+  module_name TEXT NOT NULL,
+  module_version TEXT NOT NULL,
+  hosted_repository TEXT NOT NULL,
+  subdirectory TEXT NOT NULL DEFAULT '',
+  is_root BOOLEAN NOT NULL
+)`,
+		`CREATE TABLE go_module_edges (caller_id TEXT NOT NULL, callee_id TEXT NOT NULL, PRIMARY KEY (caller_id, callee_id))`,
+		`INSERT INTO go_modules VALUES ('a', 'github.com/owner/repo', 'go.mod', 'example.com/a', '', 'example.com/a', '', TRUE)`,
+		`INSERT INTO go_modules VALUES ('b', 'github.com/owner/repo', 'go.mod', 'example.com/b', 'v1.0.0', 'example.com/monorepo', 'b', FALSE)`,
+		`INSERT INTO go_modules VALUES ('c', 'github.com/owner/repo', 'go.mod', 'example.com/c', 'v1.0.0', 'example.com/c', '', FALSE)`,
+		`INSERT INTO go_module_edges VALUES ('a', 'b'), ('a', 'c'), ('b', 'c')`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newGraph := func() *sqlGoModuleGraph {
+		cols := "id, name_with_owner, go_mod_path, module_name, module_version, hosted_repository, subdirectory, is_root"
+		return &sqlGoModuleGraph{
+			db:               db,
+			selectModules:    "SELECT " + cols + " FROM go_modules",
+			selectModuleByID: "SELECT " + cols + " FROM go_modules WHERE id = ?",
+			selectEdges:      "SELECT caller_id, callee_id FROM go_module_edges",
+			selectCallees:    "SELECT callee_id FROM go_module_edges WHERE caller_id = ?",
+			selectCallers:    "SELECT caller_id FROM go_module_edges WHERE callee_id = ?",
+			cache:            make(map[string]*GoModule),
+		}
+	}
+
+	t.Run("roots", func(t *testing.T) {
+		roots, err := newGraph().findRoots(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(roots) != 1 || roots[0].ID != "a" {
+			t.Fatalf("unexpected roots: %+v", roots)
+		}
+		refers := roots[0].Refers
+		if len(refers) != 2 || refers[0].ID != "b" || refers[1].ID != "c" {
+			t.Fatalf("unexpected refers: %+v", refers)
+		}
+		if refers[0].Subdirectory != "b" || len(refers[1].Referers) != 2 {
+			t.Fatalf("unexpected modules: %+v", refers)
+		}
+	})
+	t.Run("by id", func(t *testing.T) {
+		g := newGraph()
+		c, err := g.findByID(ctx, "c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(c.Referers) != 2 || c.Referers[0].ID != "a" || c.Referers[1].ID != "b" {
+			t.Fatalf("unexpected referers: %+v", c.Referers)
+		}
+		// the module referred from multiple modules is the same instance.
+		if c.Referers[0].Refers[1] != c || c.Referers[1].Refers[0] != c {
+			t.Fatal("failed to share the loaded module")
+		}
+		if _, err := g.findByID(ctx, "unknown"); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
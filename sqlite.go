@@ -3,53 +3,189 @@ package modrank
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
 	_ "github.com/glebarez/go-sqlite"
 )
 
-var _ Storage = new(SQLiteStorage)
+var (
+	_ Storage           = new(SQLiteStorage)
+	_ MigratableStorage = new(SQLiteStorage)
+)
 
 type SQLiteStorage struct {
-	db       *sql.DB
-	modCache map[string]*GoModule
+	db                   *sql.DB
+	graph                *sqlGoModuleGraph
+	migrator             *sqlMigrator
+	legacyRepositoryHost string
+}
+
+type SQLiteStorageOption func(*SQLiteStorage)
+
+// SQLiteLegacyRepositoryHost specify the host name of the repositories stored by the older version as owner/repo. e.g.) github.com
+// The older version doesn't record the host name, so the legacy names are qualified with this host by the migration.
+// If it is not specified, the legacy data is left as is and the repositories are scanned again.
+// It must be specified when the database created by the older version is migrated first.
+func SQLiteLegacyRepositoryHost(host string) SQLiteStorageOption {
+	return func(s *SQLiteStorage) {
+		s.legacyRepositoryHost = host
+	}
 }
 
-func NewSQLiteStorage(dsn string) (*SQLiteStorage, error) {
+func NewSQLiteStorage(dsn string, opts ...SQLiteStorageOption) (*SQLiteStorage, error) {
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, err
 	}
-	return &SQLiteStorage{
-		db:       db,
-		modCache: make(map[string]*GoModule),
-	}, nil
+	s := &SQLiteStorage{
+		db: db,
+		graph: &sqlGoModuleGraph{
+			db:               db,
+			selectModules:    "SELECT ID, NameWithOwner, GoModPath, ModuleName, ModuleVersion, HostedRepository, Subdirectory, IsRoot FROM GoModules",
+			selectModuleByID: "SELECT ID, NameWithOwner, GoModPath, ModuleName, ModuleVersion, HostedRepository, Subdirectory, IsRoot FROM GoModules WHERE ID = ?",
+			selectEdges:      "SELECT CallerID, CalleeID FROM GoModuleEdges",
+			selectCallees:    "SELECT CalleeID FROM GoModuleEdges WHERE CallerID = ?",
+			selectCallers:    "SELECT CallerID FROM GoModuleEdges WHERE CalleeID = ?",
+			cache:            make(map[string]*GoModule),
+		},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.migrator = &sqlMigrator{
+		db:         db,
+		migrations: s.migrations(),
+		createVersionTable: `
+CREATE TABLE IF NOT EXISTS SchemaMigrations (
+  Version INTEGER PRIMARY KEY NOT NULL,
+  Description TEXT NOT NULL,
+  AppliedAt INTEGER NOT NULL
+)`,
+		existsVersionTable: "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'SchemaMigrations'",
+		selectVersions:     "SELECT Version, Description, AppliedAt FROM SchemaMigrations",
+		insertVersion:      "INSERT INTO SchemaMigrations(Version, Description, AppliedAt) VALUES (?, ?, ?)",
+		// the no-op write takes the write lock of the database file, so the migrations by multiple processes are serialized.
+		lockTx: "DELETE FROM SchemaMigrations WHERE 0",
+	}
+	return s, nil
 }
 
+// migrations returns the migrations of the schema in order. Never change the applied migrations,
+// but append the new migration to change the schema.
+func (s *SQLiteStorage) migrations() []*sqlMigration {
+	return []*sqlMigration{
+		{version: 1, description: "create Repositories table", migrate: s.createRepositoryTable},
+		{version: 2, description: "create GoModules table", migrate: s.createGoModuleTable},
+		{version: 3, description: "create HostedRepositories table", migrate: s.createHostedRepositoryTable},
+		{version: 4, description: "move edges of Go modules from JSON columns to GoModuleEdges table", migrate: s.createGoModuleEdgeTable},
+		{version: 5, description: "qualify legacy repository names with host name", migrate: s.qualifyLegacyRepositoryNames},
+		{version: 6, description: "add CanonicalName column to Repositories table", migrate: s.addCanonicalNameColumn},
+		{version: 7, description: "add Subdirectory column to GoModules table", migrate: s.addSubdirectoryColumn},
+	}
+}
+
+// Migrate applies the pending migrations of the schema.
+func (s *SQLiteStorage) Migrate(ctx context.Context) error {
+	return s.migrator.migrate(ctx)
+}
+
+func (s *SQLiteStorage) MigrationStatus(ctx context.Context) ([]*MigrationStatus, error) {
+	return s.migrator.status(ctx)
+}
+
+// Close closes the database.
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+// CreateRepositoryStorageIfNotExists applies the pending migrations of the schema.
 func (s *SQLiteStorage) CreateRepositoryStorageIfNotExists(ctx context.Context) error {
-	if _, err := s.db.ExecContext(ctx,
+	return s.migrator.ensure(ctx)
+}
+
+func (s *SQLiteStorage) createRepositoryTable(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx,
 		`
 CREATE TABLE IF NOT EXISTS Repositories (
   NameWithOwner TEXT PRIMARY KEY NOT NULL,
   Head TEXT NOT NULL,
   IsArchived BOOL NOT NULL,
-  ExistsGoMod BOOL NOT NULL
+  ExistsGoMod BOOL NOT NULL,
+  NotFound BOOL NOT NULL DEFAULT FALSE
 )`,
 	); err != nil {
 		return err
 	}
+	// the database created by the older version doesn't have NotFound column.
+	if err := s.addColumnIfNotExists(ctx, tx, "Repositories", "NotFound", "BOOL NOT NULL DEFAULT FALSE"); err != nil {
+		return err
+	}
 	return nil
 }
 
+func (s *SQLiteStorage) addCanonicalNameColumn(ctx context.Context, tx *sql.Tx) error {
+	return s.addColumnIfNotExists(ctx, tx, "Repositories", "CanonicalName", "TEXT NOT NULL DEFAULT ''")
+}
+
+func (s *SQLiteStorage) addSubdirectoryColumn(ctx context.Context, tx *sql.Tx) error {
+	return s.addColumnIfNotExists(ctx, tx, "GoModules", "Subdirectory", "TEXT NOT NULL DEFAULT ''")
+}
+
+func (s *SQLiteStorage) addColumnIfNotExists(ctx context.Context, exec sqlExecutor, table, column, definition string) error {
+	exists, err := s.existsColumn(ctx, exec, table, column)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	if _, err := exec.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) existsColumn(ctx context.Context, exec sqlExecutor, table, column string) (bool, error) {
+	rows, err := exec.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			typ        string
+			notNull    bool
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultVal, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
 func (s *SQLiteStorage) FindRepositoryByName(ctx context.Context, nameWithOwner string) (*RepositoryStatus, error) {
 	var (
 		headCommitHash string
 		isArchived     bool
 		existsGoMod    bool
+		notFound       bool
+		canonicalName  string
 	)
 	if err := s.db.QueryRowContext(
-		ctx, "SELECT Head, IsArchived, ExistsGoMod FROM Repositories WHERE NameWithOwner = ?", nameWithOwner,
-	).Scan(&headCommitHash, &isArchived, &existsGoMod); err != nil {
+		ctx, "SELECT Head, IsArchived, ExistsGoMod, NotFound, CanonicalName FROM Repositories WHERE NameWithOwner = ?", nameWithOwner,
+	).Scan(&headCommitHash, &isArchived, &existsGoMod, &notFound, &canonicalName); err != nil {
 		return nil, err
 	}
 	return &RepositoryStatus{
@@ -57,6 +193,8 @@ func (s *SQLiteStorage) FindRepositoryByName(ctx context.Context, nameWithOwner
 		HeadCommitHash: headCommitHash,
 		IsArchived:     isArchived,
 		ExistsGoMod:    existsGoMod,
+		NotFound:       notFound,
+		CanonicalName:  canonicalName,
 	}, nil
 }
 
@@ -64,22 +202,27 @@ func (s *SQLiteStorage) InsertOrUpdateRepository(ctx context.Context, st *Reposi
 	if _, err := s.db.ExecContext(
 		ctx, `
 INSERT INTO
-  Repositories(NameWithOwner, Head, IsArchived, ExistsGoMod) VALUES (?, ?, ?, ?)
+  Repositories(NameWithOwner, Head, IsArchived, ExistsGoMod, NotFound, CanonicalName) VALUES (?, ?, ?, ?, ?, ?)
 ON CONFLICT(NameWithOwner)
 DO UPDATE
-  SET Head = ?, IsArchived = ?, ExistsGoMod = ?
+  SET Head = ?, IsArchived = ?, ExistsGoMod = ?, NotFound = ?, CanonicalName = ?
 `,
-		st.NameWithOwner, st.HeadCommitHash, st.IsArchived, st.ExistsGoMod,
+		st.NameWithOwner, st.HeadCommitHash, st.IsArchived, st.ExistsGoMod, st.NotFound, st.CanonicalName,
 
-		st.HeadCommitHash, st.IsArchived, st.ExistsGoMod,
+		st.HeadCommitHash, st.IsArchived, st.ExistsGoMod, st.NotFound, st.CanonicalName,
 	); err != nil {
 		return err
 	}
 	return nil
 }
 
+// CreateGoModuleStorageIfNotExists applies the pending migrations of the schema.
 func (s *SQLiteStorage) CreateGoModuleStorageIfNotExists(ctx context.Context) error {
-	if _, err := s.db.ExecContext(ctx,
+	return s.migrator.ensure(ctx)
+}
+
+func (s *SQLiteStorage) createGoModuleTable(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx,
 		`
 CREATE TABLE IF NOT EXISTS GoModules (
   ID TEXT PRIMARY KEY NOT NULL,
@@ -88,9 +231,7 @@ CREATE TABLE IF NOT EXISTS GoModules (
   ModuleName TEXT NOT NULL,
   ModuleVersion TEXT NOT NULL,
   HostedRepository TEXT NOT NULL,
-  IsRoot BOOL NOT NULL,
-  Refers JSON NOT NULL,
-  Referers JSON NOT NULL
+  IsRoot BOOL NOT NULL
 )`,
 	); err != nil {
 		return err
@@ -98,103 +239,173 @@ CREATE TABLE IF NOT EXISTS GoModules (
 	return nil
 }
 
-func (s *SQLiteStorage) FindRootGoModules(ctx context.Context) ([]*GoModule, error) {
-	rows, err := s.db.QueryContext(
-		ctx,
-		`SELECT ID, NameWithOwner, GoModPath, ModuleName, ModuleVersion, HostedRepository, Refers, Referers
-           FROM GoModules WHERE IsRoot = TRUE`,
-	)
+// createGoModuleEdgeTable creates the table of the edges between modules and the indexes to load the graph.
+// The database created by the older version stores the edges as the JSON arrays in Refers and Referers columns,
+// so the edges in Refers column are moved to the table and the JSON columns are dropped.
+// Referers column is dropped without moving because it is the reverse of Refers.
+func (s *SQLiteStorage) createGoModuleEdgeTable(ctx context.Context, tx *sql.Tx) error {
+	for _, stmt := range []string{
+		`
+CREATE TABLE IF NOT EXISTS GoModuleEdges (
+  CallerID TEXT NOT NULL,
+  CalleeID TEXT NOT NULL,
+  PRIMARY KEY (CallerID, CalleeID)
+)`,
+		"CREATE INDEX IF NOT EXISTS GoModulesModuleNameIndex ON GoModules (ModuleName)",
+		"CREATE INDEX IF NOT EXISTS GoModulesNameWithOwnerIndex ON GoModules (NameWithOwner)",
+		"CREATE INDEX IF NOT EXISTS GoModulesIsRootIndex ON GoModules (IsRoot)",
+		"CREATE INDEX IF NOT EXISTS GoModuleEdgesCalleeIDIndex ON GoModuleEdges (CalleeID)",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	exists, err := s.existsColumn(ctx, tx, "GoModules", "Refers")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	var roots []*GoModule
-	for rows.Next() {
-		var (
-			id             string
-			nameWithOwner  string
-			goModPath      string
-			modName        string
-			modVer         string
-			hostedRepo     string
-			referIDsJSON   string
-			refererIDsJSON string
-		)
-		if err := rows.Scan(&id, &nameWithOwner, &goModPath, &modName, &modVer, &hostedRepo, &referIDsJSON, &refererIDsJSON); err != nil {
-			break
-		}
-		rootMod := &GoModule{
-			ID:               id,
-			Repository:       nameWithOwner,
-			GoModPath:        goModPath,
-			Name:             modName,
-			Version:          modVer,
-			HostedRepository: hostedRepo,
+	if !exists {
+		return nil
+	}
+	for _, stmt := range []string{
+		`INSERT OR IGNORE INTO GoModuleEdges(CallerID, CalleeID)
+           SELECT GoModules.ID, json_each.value FROM GoModules, json_each(GoModules.Refers)`,
+		"ALTER TABLE GoModules DROP COLUMN Refers",
+		"ALTER TABLE GoModules DROP COLUMN Referers",
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
 		}
-		s.modCache[id] = rootMod
+	}
+	return nil
+}
 
-		refers, err := s.findModulesFromJSON(ctx, referIDsJSON)
-		if err != nil {
-			return nil, err
+// qualifyLegacyRepositoryNames rewrites the repository names stored by the older version as owner/repo to host/owner/repo
+// by the host specified by SQLiteLegacyRepositoryHost option.
+// The IDs of Go modules are hashed from the repository name, so they are also rewritten with the edges.
+// If the repository is already stored with the qualified name, the legacy data of the repository is left as is
+// because the stored data is newer. The legacy data is never referred by the qualified repository.
+func (s *SQLiteStorage) qualifyLegacyRepositoryNames(ctx context.Context, tx *sql.Tx) error {
+	if s.legacyRepositoryHost == "" {
+		return nil
+	}
+	names, err := s.legacyRepositoryNames(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for _, legacyName := range names {
+		name := s.legacyRepositoryHost + "/" + legacyName
+		var exists bool
+		if err := tx.QueryRowContext(
+			ctx,
+			"SELECT EXISTS (SELECT 1 FROM Repositories WHERE NameWithOwner = ?) OR EXISTS (SELECT 1 FROM GoModules WHERE NameWithOwner = ?)",
+			name, name,
+		).Scan(&exists); err != nil {
+			return err
 		}
-		referers, err := s.findModulesFromJSON(ctx, refererIDsJSON)
-		if err != nil {
-			return nil, err
+		if exists {
+			continue
+		}
+		if err := s.qualifyLegacyGoModules(ctx, tx, legacyName, name); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE Repositories SET NameWithOwner = ? WHERE NameWithOwner = ?", name, legacyName); err != nil {
+			return err
 		}
-		rootMod.Refers = refers
-		rootMod.Referers = referers
-		roots = append(roots, rootMod)
 	}
-	return roots, nil
+	return nil
 }
 
-func (s *SQLiteStorage) FindGoModuleByID(ctx context.Context, id string) (*GoModule, error) {
-	if mod, exists := s.modCache[id]; exists {
-		return mod, nil
-	}
-
-	var (
-		nameWithOwner  string
-		goModPath      string
-		modName        string
-		modVer         string
-		hostedRepo     string
-		referIDsJSON   string
-		refererIDsJSON string
+// legacyRepositoryNames returns the repository names stored by the older version as owner/repo.
+func (s *SQLiteStorage) legacyRepositoryNames(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+SELECT NameWithOwner FROM Repositories WHERE NameWithOwner NOT LIKE '%/%/%'
+UNION
+SELECT NameWithOwner FROM GoModules WHERE NameWithOwner NOT LIKE '%/%/%'`,
 	)
-	if err := s.db.QueryRowContext(ctx,
-		`SELECT NameWithOwner, GoModPath, ModuleName, ModuleVersion, HostedRepository, Refers, Referers
-           FROM GoModules WHERE ID = ?`, id,
-	).Scan(&nameWithOwner, &goModPath, &modName, &modVer, &hostedRepo, &referIDsJSON, &refererIDsJSON); err != nil {
+	if err != nil {
 		return nil, err
 	}
-	mod := &GoModule{
-		ID:               id,
-		Repository:       nameWithOwner,
-		GoModPath:        goModPath,
-		Name:             modName,
-		Version:          modVer,
-		HostedRepository: hostedRepo,
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
 	}
-	s.modCache[id] = mod
-
-	refers, err := s.findModulesFromJSON(ctx, referIDsJSON)
-	if err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	referers, err := s.findModulesFromJSON(ctx, refererIDsJSON)
+	return names, nil
+}
+
+func (s *SQLiteStorage) qualifyLegacyGoModules(ctx context.Context, tx *sql.Tx, legacyName, name string) error {
+	type legacyGoModule struct {
+		id        string
+		goModPath string
+		name      string
+		version   string
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT ID, GoModPath, ModuleName, ModuleVersion FROM GoModules WHERE NameWithOwner = ?", legacyName)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	var mods []*legacyGoModule
+	for rows.Next() {
+		var mod legacyGoModule
+		if err := rows.Scan(&mod.id, &mod.goModPath, &mod.name, &mod.version); err != nil {
+			rows.Close()
+			return err
+		}
+		mods = append(mods, &mod)
 	}
-	mod.Refers = refers
-	mod.Referers = referers
-	return mod, nil
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	// the qualified repository has no modules, so the new IDs never collide with the stored modules.
+	for _, mod := range mods {
+		id := goModuleID(name, mod.goModPath, mod.name, mod.version)
+		if _, err := tx.ExecContext(ctx, "UPDATE GoModules SET ID = ?, NameWithOwner = ? WHERE ID = ?", id, name, mod.id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE GoModuleEdges SET CallerID = ? WHERE CallerID = ?", id, mod.id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE GoModuleEdges SET CalleeID = ? WHERE CalleeID = ?", id, mod.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) FindRootGoModules(ctx context.Context) ([]*GoModule, error) {
+	return s.graph.findRoots(ctx)
 }
 
+func (s *SQLiteStorage) FindGoModuleByID(ctx context.Context, id string) (*GoModule, error) {
+	return s.graph.findByID(ctx, id)
+}
+
+// InsertOrUpdateGoModules replaces the modules and the edges of the repository in a transaction.
 func (s *SQLiteStorage) InsertOrUpdateGoModules(ctx context.Context, nameWithOwner string, mods []*GoModule) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer func() { _ = tx.Rollback() }()
+
+	// the edges from the modules no longer used by the repository are also deleted.
+	if _, err := tx.ExecContext(
+		ctx,
+		"DELETE FROM GoModuleEdges WHERE CallerID IN (SELECT ID FROM GoModules WHERE NameWithOwner = ?)",
+		nameWithOwner,
+	); err != nil {
+		return err
+	}
+
 	for _, mod := range mods {
 		if err := s.insertOrUpdateGoModule(ctx, tx, mod); err != nil {
 			return err
@@ -207,24 +418,6 @@ func (s *SQLiteStorage) InsertOrUpdateGoModules(ctx context.Context, nameWithOwn
 }
 
 func (s *SQLiteStorage) insertOrUpdateGoModule(ctx context.Context, tx *sql.Tx, mod *GoModule) error {
-	refers := mod.Refers
-	referers := mod.Referers
-	referIDs := make([]string, 0, len(refers))
-	for _, ref := range refers {
-		referIDs = append(referIDs, ref.ID)
-	}
-	refererIDs := make([]string, 0, len(referers))
-	for _, ref := range referers {
-		refererIDs = append(refererIDs, ref.ID)
-	}
-	referIDsJSON, err := json.Marshal(referIDs)
-	if err != nil {
-		return err
-	}
-	refererIDsJSON, err := json.Marshal(refererIDs)
-	if err != nil {
-		return err
-	}
 	if _, err := tx.ExecContext(
 		ctx, `
 INSERT INTO
@@ -235,9 +428,8 @@ INSERT INTO
     ModuleName,
     ModuleVersion,
     HostedRepository,
-    IsRoot,
-    Refers,
-    Referers
+    Subdirectory,
+    IsRoot
   ) VALUES (
     ?,
     ?,
@@ -246,35 +438,132 @@ INSERT INTO
     ?,
     ?,
     ?,
-    ?,
     ?
   )
 ON CONFLICT(ID)
   DO UPDATE
-    SET IsRoot = ?, Refers = ?, Referers = ?
+    SET IsRoot = ?, HostedRepository = ?, Subdirectory = ?
 `,
-		mod.ID, mod.Repository, mod.GoModPath, mod.Name, mod.Version, mod.HostedRepository,
-		mod.IsRoot(), string(referIDsJSON), string(refererIDsJSON),
+		mod.ID, mod.Repository, mod.GoModPath, mod.Name, mod.Version, mod.HostedRepository, mod.Subdirectory, mod.IsRoot(),
 
-		mod.IsRoot(), string(referIDsJSON), string(refererIDsJSON),
+		mod.IsRoot(), mod.HostedRepository, mod.Subdirectory,
 	); err != nil {
 		return err
 	}
+	for _, ref := range mod.Refers {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO GoModuleEdges(CallerID, CalleeID) VALUES (?, ?)", mod.ID, ref.ID,
+		); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s *SQLiteStorage) findModulesFromJSON(ctx context.Context, jsonText string) ([]*GoModule, error) {
-	var ids []string
-	if err := json.Unmarshal([]byte(jsonText), &ids); err != nil {
+// CreateHostedRepositoryStorageIfNotExists applies the pending migrations of the schema.
+func (s *SQLiteStorage) CreateHostedRepositoryStorageIfNotExists(ctx context.Context) error {
+	return s.migrator.ensure(ctx)
+}
+
+func (s *SQLiteStorage) createHostedRepositoryTable(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx,
+		`
+CREATE TABLE IF NOT EXISTS HostedRepositories (
+  ModulePath TEXT PRIMARY KEY NOT NULL,
+  Repository TEXT NOT NULL,
+  Subdirectory TEXT NOT NULL DEFAULT '',
+  ResolvedAt INTEGER NOT NULL,
+  IsOverride BOOL NOT NULL
+)`,
+	); err != nil {
+		return err
+	}
+	if err := s.addColumnIfNotExists(ctx, tx, "HostedRepositories", "Subdirectory", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindHostedRepository returns nil without error if the mapping of the module is not stored.
+func (s *SQLiteStorage) FindHostedRepository(ctx context.Context, modulePath string) (*HostedRepositoryMapping, error) {
+	var (
+		repo       string
+		subdir     string
+		resolvedAt int64
+		isOverride bool
+	)
+	if err := s.db.QueryRowContext(
+		ctx, "SELECT Repository, Subdirectory, ResolvedAt, IsOverride FROM HostedRepositories WHERE ModulePath = ?", modulePath,
+	).Scan(&repo, &subdir, &resolvedAt, &isOverride); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &HostedRepositoryMapping{
+		ModulePath:   modulePath,
+		Repository:   repo,
+		Subdirectory: subdir,
+		ResolvedAt:   time.Unix(resolvedAt, 0),
+		IsOverride:   isOverride,
+	}, nil
+}
+
+func (s *SQLiteStorage) FindHostedRepositories(ctx context.Context) ([]*HostedRepositoryMapping, error) {
+	rows, err := s.db.QueryContext(
+		ctx, "SELECT ModulePath, Repository, Subdirectory, ResolvedAt, IsOverride FROM HostedRepositories ORDER BY ModulePath",
+	)
+	if err != nil {
 		return nil, err
 	}
-	mods := make([]*GoModule, 0, len(ids))
-	for _, id := range ids {
-		mod, err := s.FindGoModuleByID(ctx, id)
-		if err != nil {
+	defer rows.Close()
+	var mappings []*HostedRepositoryMapping
+	for rows.Next() {
+		var (
+			modulePath string
+			repo       string
+			subdir     string
+			resolvedAt int64
+			isOverride bool
+		)
+		if err := rows.Scan(&modulePath, &repo, &subdir, &resolvedAt, &isOverride); err != nil {
 			return nil, err
 		}
-		mods = append(mods, mod)
+		mappings = append(mappings, &HostedRepositoryMapping{
+			ModulePath:   modulePath,
+			Repository:   repo,
+			Subdirectory: subdir,
+			ResolvedAt:   time.Unix(resolvedAt, 0),
+			IsOverride:   isOverride,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+func (s *SQLiteStorage) InsertOrUpdateHostedRepository(ctx context.Context, mapping *HostedRepositoryMapping) error {
+	if _, err := s.db.ExecContext(
+		ctx, `
+INSERT INTO
+  HostedRepositories(ModulePath, Repository, Subdirectory, ResolvedAt, IsOverride) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(ModulePath)
+DO UPDATE
+  SET Repository = ?, Subdirectory = ?, ResolvedAt = ?, IsOverride = ?
+`,
+		mapping.ModulePath, mapping.Repository, mapping.Subdirectory, mapping.ResolvedAt.Unix(), mapping.IsOverride,
+
+		mapping.Repository, mapping.Subdirectory, mapping.ResolvedAt.Unix(), mapping.IsOverride,
+	); err != nil {
+		return err
 	}
-	return mods, nil
+	return nil
+}
+
+func (s *SQLiteStorage) DeleteHostedRepository(ctx context.Context, modulePath string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM HostedRepositories WHERE ModulePath = ?", modulePath); err != nil {
+		return err
+	}
+	return nil
 }
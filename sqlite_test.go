@@ -0,0 +1,231 @@
+package modrank
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func TestSQLiteStorageMigrateGoModuleEdgesFromJSON(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the schema created by the older version.
+	for _, stmt := range []string{
+		`
+CREATE TABLE GoModules (
+  ID TEXT PRIMARY KEY NOT NULL,
+  NameWithOwner TEXT NOT NULL,
+  GoModPath TEXT NOT NULL,
+  ModuleName TEXT NOT NULL,
+  ModuleVersion TEXT NOT NULL,
+  HostedRepository TEXT NOT NULL,
+  IsRoot BOOL NOT NULL,
+  Refers JSON NOT NULL,
+  Referers JSON NOT NULL
+)`,
+		`INSERT INTO GoModules VALUES ('a', 'github.com/owner/repo', 'go.mod', 'example.com/a', 'v1.0.0', 'example.com/a', TRUE, '["b","c"]', '[]')`,
+		`INSERT INTO GoModules VALUES ('b', 'github.com/owner/repo', 'go.mod', 'example.com/b', 'v1.0.0', 'example.com/b', FALSE, '["c"]', '["a"]')`,
+		`INSERT INTO GoModules VALUES ('c', 'github.com/owner/repo', 'go.mod', 'example.com/c', 'v1.0.0', 'example.com/c', FALSE, '[]', '["a","b"]')`,
+	} {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the migration runs only once.
+	for range 2 {
+		if err := s.CreateGoModuleStorageIfNotExists(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists, err := s.existsColumn(ctx, s.db, "GoModules", "Refers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatal("failed to drop JSON column")
+	}
+	roots, err := s.FindRootGoModules(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || roots[0].ID != "a" {
+		t.Fatalf("unexpected roots: %+v", roots)
+	}
+	if len(roots[0].Refers) != 2 || roots[0].Refers[0].ID != "b" || roots[0].Refers[1].ID != "c" {
+		t.Fatalf("unexpected refers: %+v", roots[0].Refers)
+	}
+	if c := roots[0].Refers[1]; len(c.Referers) != 2 {
+		t.Fatalf("unexpected referers: %+v", c.Referers)
+	}
+}
+
+func TestSQLiteStorageMigrateLegacyRepositoryNames(t *testing.T) {
+	ctx := context.Background()
+	// the data stored by the older version identifying the repository by owner/repo.
+	legacyStmts := []string{
+		"CREATE TABLE Repositories (NameWithOwner TEXT PRIMARY KEY NOT NULL, Head TEXT NOT NULL, IsArchived BOOL NOT NULL, ExistsGoMod BOOL NOT NULL)",
+		"INSERT INTO Repositories VALUES ('owner/repo', 'head', FALSE, TRUE)",
+		"INSERT INTO Repositories VALUES ('owner/dup', 'legacy', FALSE, TRUE)",
+		"INSERT INTO Repositories VALUES ('gitlab.example.com/owner/dup', 'new', FALSE, TRUE)",
+		`
+CREATE TABLE GoModules (
+  ID TEXT PRIMARY KEY NOT NULL,
+  NameWithOwner TEXT NOT NULL,
+  GoModPath TEXT NOT NULL,
+  ModuleName TEXT NOT NULL,
+  ModuleVersion TEXT NOT NULL,
+  HostedRepository TEXT NOT NULL,
+  IsRoot BOOL NOT NULL,
+  Refers JSON NOT NULL,
+  Referers JSON NOT NULL
+)`,
+		`INSERT INTO GoModules VALUES ('a', 'owner/repo', 'go.mod', 'example.com/a', '', 'example.com/a', TRUE, '["b"]', '[]')`,
+		`INSERT INTO GoModules VALUES ('b', 'owner/repo', 'go.mod', 'example.com/b', 'v1.0.0', 'example.com/b', FALSE, '[]', '["a"]')`,
+	}
+	newLegacyStorage := func(t *testing.T, opts ...SQLiteStorageOption) *SQLiteStorage {
+		t.Helper()
+		s, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		for _, stmt := range legacyStmts {
+			if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.Migrate(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	legacyStatus := func(t *testing.T, s *SQLiteStorage, name string) string {
+		t.Helper()
+		var head string
+		if err := s.db.QueryRowContext(ctx, "SELECT Head FROM Repositories WHERE NameWithOwner = ?", name).Scan(&head); err != nil {
+			t.Fatal(err)
+		}
+		return head
+	}
+
+	t.Run("qualify", func(t *testing.T) {
+		s := newLegacyStorage(t, SQLiteLegacyRepositoryHost("gitlab.example.com"))
+		st, err := s.FindRepositoryByName(ctx, "gitlab.example.com/owner/repo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.HeadCommitHash != "head" || !st.ExistsGoMod {
+			t.Fatalf("unexpected repository status: %+v", st)
+		}
+		roots, err := s.FindRootGoModules(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(roots) != 1 {
+			t.Fatalf("unexpected roots: %+v", roots)
+		}
+		root := roots[0]
+		if root.Repository != "gitlab.example.com/owner/repo" || root.ID != goModuleID("gitlab.example.com/owner/repo", "go.mod", "example.com/a", "") {
+			t.Fatalf("unexpected root: %+v", root)
+		}
+		if len(root.Refers) != 1 || root.Refers[0].ID != goModuleID("gitlab.example.com/owner/repo", "go.mod", "example.com/b", "v1.0.0") {
+			t.Fatalf("unexpected refers: %+v", root.Refers)
+		}
+		// the repository already stored with the qualified name is not overwritten by the legacy data.
+		if head := legacyStatus(t, s, "gitlab.example.com/owner/dup"); head != "new" {
+			t.Fatalf("unexpected head of qualified repository: %s", head)
+		}
+		if head := legacyStatus(t, s, "owner/dup"); head != "legacy" {
+			t.Fatalf("unexpected head of legacy repository: %s", head)
+		}
+	})
+	t.Run("unknown host", func(t *testing.T) {
+		s := newLegacyStorage(t)
+		if head := legacyStatus(t, s, "owner/repo"); head != "head" {
+			t.Fatalf("unexpected head of legacy repository: %s", head)
+		}
+		if _, err := s.FindRepositoryByName(ctx, "github.com/owner/repo"); err == nil {
+			t.Fatal("legacy repository must not be qualified without the host")
+		}
+	})
+}
+
+func TestSQLiteStorageMigration(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(ctx, WithStorage(s), WithoutAutoMigration()); err == nil {
+		t.Fatal("expected error for pending migrations")
+	}
+	statuses, err := s.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending := PendingMigrations(statuses); len(pending) != len(s.migrations()) {
+		t.Fatalf("unexpected pending migrations: %d", len(pending))
+	}
+	// the status doesn't create the schema version table.
+	var num int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'SchemaMigrations'").Scan(&num); err != nil {
+		t.Fatal(err)
+	}
+	if num != 0 {
+		t.Fatal("unexpected schema version table created by the status")
+	}
+
+	if _, err := New(ctx, WithStorage(s)); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err = s.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending := PendingMigrations(statuses); len(pending) != 0 {
+		t.Fatalf("failed to apply migrations: %d", len(pending))
+	}
+	if _, err := New(ctx, WithStorage(s), WithoutAutoMigration()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the database migrated by the newer version must not be used.
+	if _, err := s.db.ExecContext(ctx, "INSERT INTO SchemaMigrations VALUES (100, 'newer', 0)"); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err = s.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != 100 || !last.Unknown {
+		t.Fatalf("unexpected status of unknown migration: %+v", last)
+	}
+	if err := s.Migrate(ctx); err == nil {
+		t.Fatal("expected error for newer schema version")
+	}
+}
+
+func TestSQLiteStorageConcurrentMigration(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	var eg errgroup.Group
+	for range 16 {
+		s, err := NewSQLiteStorage(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		// the storages don't share the connection as the separated processes.
+		eg.Go(func() error {
+			return s.Migrate(ctx)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
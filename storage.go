@@ -1,6 +1,10 @@
 package modrank
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 type Storage interface {
 	RepositoryStorage
@@ -19,3 +23,31 @@ type GoModuleStorage interface {
 	FindGoModuleByID(ctx context.Context, id string) (*GoModule, error)
 	InsertOrUpdateGoModules(ctx context.Context, nameWithOwner string, mods []*GoModule) error
 }
+
+// HostedRepositoryStorage is the optional interface of Storage to persist the hosted repositories of Go modules.
+// If the storage doesn't implement it, the hosted repositories are resolved again in each process
+// and the override mappings can't be stored.
+type HostedRepositoryStorage interface {
+	CreateHostedRepositoryStorageIfNotExists(ctx context.Context) error
+	FindHostedRepository(ctx context.Context, modulePath string) (*HostedRepositoryMapping, error)
+	FindHostedRepositories(ctx context.Context) ([]*HostedRepositoryMapping, error)
+	InsertOrUpdateHostedRepository(ctx context.Context, mapping *HostedRepositoryMapping) error
+	DeleteHostedRepository(ctx context.Context, modulePath string) error
+}
+
+// ErrHostedRepositoryStorageNotSupported is the error for the storage that doesn't implement HostedRepositoryStorage.
+var ErrHostedRepositoryStorageNotSupported = errors.New("storage doesn't support storing hosted repositories")
+
+// HostedRepositoryMapping is the mapping from the Go module path to the repository hosting the module.
+type HostedRepositoryMapping struct {
+	// ModulePath is the Go module path. e.g.) gopkg.in/yaml.v3
+	ModulePath string
+	// Repository is the hosted repository name. e.g.) github.com/go-yaml/yaml
+	Repository string
+	// Subdirectory is the directory containing the module within the repository. e.g.) go
+	Subdirectory string
+	// ResolvedAt is the time when the mapping is resolved. The mapping is resolved again after the TTL expires.
+	ResolvedAt time.Time
+	// IsOverride whether the mapping is specified manually. The override mapping never expires.
+	IsOverride bool
+}
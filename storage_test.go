@@ -0,0 +1,283 @@
+package modrank_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-modrank"
+)
+
+func TestStorage(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		s, err := modrank.NewMemoryStorage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		testStorage(t, s)
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		s, err := modrank.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		testStorage(t, s)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("MODRANK_TEST_POSTGRES_DSN")
+		if dsn == "" {
+			t.Skip("MODRANK_TEST_POSTGRES_DSN is not set")
+		}
+		s, err := modrank.NewPostgresStorage(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testStorage(t, s)
+	})
+	t.Run("mysql", func(t *testing.T) {
+		dsn := os.Getenv("MODRANK_TEST_MYSQL_DSN")
+		if dsn == "" {
+			t.Skip("MODRANK_TEST_MYSQL_DSN is not set")
+		}
+		s, err := modrank.NewMySQLStorage(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testStorage(t, s)
+	})
+}
+
+func TestMemoryStorageSnapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	s, err := modrank.NewMemoryStorage(modrank.MemorySnapshotPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+	if err := s.InsertOrUpdateHostedRepository(ctx, &modrank.HostedRepositoryMapping{
+		ModulePath: "example.com/mod",
+		Repository: "github.com/owner/mod",
+		IsOverride: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := modrank.NewMemoryStorage(modrank.MemorySnapshotPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping, err := restored.FindHostedRepository(ctx, "example.com/mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping == nil || mapping.Repository != "github.com/owner/mod" || !mapping.IsOverride {
+		t.Fatalf("failed to restore mapping: %+v", mapping)
+	}
+	roots, err := restored.FindRootGoModules(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || roots[0].Name != "example.com/a" || len(roots[0].Refers) != 1 {
+		t.Fatalf("failed to restore modules: %+v", roots)
+	}
+}
+
+func TestStorageWithoutHostedRepositoryStorage(t *testing.T) {
+	ctx := context.Background()
+	mem, err := modrank.NewMemoryStorage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the storage implementing only the required interfaces.
+	s := struct {
+		modrank.RepositoryStorage
+		modrank.GoModuleStorage
+	}{mem, mem}
+	r, err := modrank.New(ctx, modrank.WithStorage(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetHostedRepository(ctx, "example.com/mod", "github.com/owner/mod"); !errors.Is(err, modrank.ErrHostedRepositoryStorageNotSupported) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.HostedRepositories(ctx); !errors.Is(err, modrank.ErrHostedRepositoryStorageNotSupported) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// hostedRepositoryStorage is implemented by all storages of this package.
+type hostedRepositoryStorage interface {
+	modrank.Storage
+	modrank.HostedRepositoryStorage
+}
+
+// testStorage tests the common behavior of Storage implementations.
+// The names are unique for each run so that the test can run against the shared database.
+func testStorage(t *testing.T, s hostedRepositoryStorage) {
+	t.Helper()
+
+	ctx := context.Background()
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	repoName := "example.com/owner/repo" + suffix
+
+	if err := s.CreateRepositoryStorageIfNotExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateGoModuleStorageIfNotExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateHostedRepositoryStorageIfNotExists(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("repository", func(t *testing.T) {
+		for _, head := range []string{"head1", "head2"} {
+			if err := s.InsertOrUpdateRepository(ctx, &modrank.RepositoryStatus{
+				NameWithOwner:  repoName,
+				HeadCommitHash: head,
+				ExistsGoMod:    true,
+				CanonicalName:  "example.com/new-owner/repo" + suffix,
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		st, err := s.FindRepositoryByName(ctx, repoName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.HeadCommitHash != "head2" || !st.ExistsGoMod || st.IsArchived || st.CanonicalName != "example.com/new-owner/repo"+suffix {
+			t.Fatalf("unexpected repository status: %+v", st)
+		}
+		if _, err := s.FindRepositoryByName(ctx, "example.com/owner/unknown"+suffix); err == nil {
+			t.Fatal("expected error for unknown repository")
+		}
+	})
+	t.Run("go module", func(t *testing.T) {
+		newMod := func(name string) *modrank.GoModule {
+			return &modrank.GoModule{
+				ID:               name + suffix,
+				Repository:       repoName,
+				GoModPath:        "go.mod",
+				Name:             name,
+				Version:          "v1.0.0",
+				HostedRepository: name,
+			}
+		}
+		a, b, c := newMod("example.com/a"), newMod("example.com/b"), newMod("example.com/c")
+		b.HostedRepository = "example.com/monorepo"
+		b.Subdirectory = "b"
+		a.Refers = []*modrank.GoModule{b, c}
+		b.Referers = []*modrank.GoModule{a}
+		b.Refers = []*modrank.GoModule{c}
+		c.Referers = []*modrank.GoModule{a, b}
+		for range 2 {
+			// the second call updates the same modules.
+			if err := s.InsertOrUpdateGoModules(ctx, repoName, []*modrank.GoModule{a, b, c}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		roots, err := s.FindRootGoModules(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var root *modrank.GoModule
+		for _, mod := range roots {
+			if mod.Repository == repoName {
+				if root != nil {
+					t.Fatalf("found multiple roots: %s, %s", root.Name, mod.Name)
+				}
+				root = mod
+			}
+		}
+		if root == nil || root.Name != "example.com/a" {
+			t.Fatalf("unexpected root module: %+v", root)
+		}
+		if len(root.Refers) != 2 || root.Refers[0].Name != "example.com/b" || root.Refers[1].Name != "example.com/c" {
+			t.Fatalf("unexpected refers: %+v", root.Refers)
+		}
+		callee := root.Refers[1]
+		if len(callee.Referers) != 2 {
+			t.Fatalf("unexpected referers: %+v", callee.Referers)
+		}
+		mod, err := s.FindGoModuleByID(ctx, b.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mod.Name != "example.com/b" || mod.Version != "v1.0.0" || mod.Subdirectory != "b" || len(mod.Refers) != 1 {
+			t.Fatalf("unexpected module: %+v", mod)
+		}
+
+		// the repository no longer uses b, so the edges from b are also replaced.
+		a.Refers = []*modrank.GoModule{c}
+		c.Referers = []*modrank.GoModule{a}
+		if err := s.InsertOrUpdateGoModules(ctx, repoName, []*modrank.GoModule{a, c}); err != nil {
+			t.Fatal(err)
+		}
+		roots, err = s.FindRootGoModules(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, mod := range roots {
+			if mod.Repository != repoName {
+				continue
+			}
+			if len(mod.Refers) != 1 || mod.Refers[0].Name != "example.com/c" {
+				t.Fatalf("unexpected refers: %+v", mod.Refers)
+			}
+			if referers := mod.Refers[0].Referers; len(referers) != 1 || referers[0].Name != "example.com/a" {
+				t.Fatalf("unexpected referers: %+v", referers)
+			}
+		}
+	})
+	t.Run("hosted repository", func(t *testing.T) {
+		modulePath := "example.com/mod" + suffix
+		resolvedAt := time.Unix(time.Now().Unix(), 0)
+		if err := s.InsertOrUpdateHostedRepository(ctx, &modrank.HostedRepositoryMapping{
+			ModulePath:   modulePath,
+			Repository:   "github.com/owner/monorepo",
+			Subdirectory: "mod",
+			ResolvedAt:   resolvedAt,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		mapping, err := s.FindHostedRepository(ctx, modulePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mapping == nil || mapping.Repository != "github.com/owner/monorepo" || mapping.Subdirectory != "mod" || !mapping.ResolvedAt.Equal(resolvedAt) {
+			t.Fatalf("unexpected mapping: %+v", mapping)
+		}
+		mappings, err := s.FindHostedRepositories(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found bool
+		for _, m := range mappings {
+			found = found || m.ModulePath == modulePath
+		}
+		if !found {
+			t.Fatal("failed to find stored mapping")
+		}
+		if err := s.DeleteHostedRepository(ctx, modulePath); err != nil {
+			t.Fatal(err)
+		}
+		mapping, err = s.FindHostedRepository(ctx, modulePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mapping != nil {
+			t.Fatalf("failed to delete mapping: %+v", mapping)
+		}
+	})
+}